@@ -0,0 +1,142 @@
+package kaitai
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrNegativePosition is returned when a seek would move the position of a
+// ByteBuffer before its beginning.
+var ErrNegativePosition = errors.New("negative position")
+
+// A ByteBuffer is a growable in-memory buffer which, unlike bytes.Buffer,
+// supports writing at arbitrary positions. Writing past the current end
+// extends the buffer, filling any gap with zero bytes.
+type ByteBuffer struct {
+	data []byte
+	pos  int64
+}
+
+// NewByteBuffer creates an empty ByteBuffer.
+func NewByteBuffer() *ByteBuffer {
+	return &ByteBuffer{}
+}
+
+// Write writes p at the current position, growing the buffer as needed, and
+// advances the position by len(p). If the buffer can't grow that large, an
+// error wrapping bytes.ErrTooLarge is returned and nothing is written.
+func (b *ByteBuffer) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	end := b.pos + int64(len(p))
+	if end < b.pos || end > int64(len(b.data)) {
+		if err := b.grow(end); err != nil {
+			return 0, fmt.Errorf("ByteBuffer.Write: error writing %d bytes at pos %d: %w", len(p), b.pos, err)
+		}
+	}
+	n := copy(b.data[b.pos:], p)
+	b.pos += int64(n)
+	return n, nil
+}
+
+// grow extends the buffer to size bytes. New bytes are zeroed. It fails with
+// bytes.ErrTooLarge if size overflows or can't be allocated.
+func (b *ByteBuffer) grow(size int64) (err error) {
+	if size < 0 || size > math.MaxInt {
+		return bytes.ErrTooLarge
+	}
+	if size <= int64(cap(b.data)) {
+		old := len(b.data)
+		b.data = b.data[:size]
+		clear(b.data[old:])
+		return nil
+	}
+	newCap := 2 * int64(cap(b.data))
+	if newCap < size {
+		newCap = size
+	}
+	// make panics for sizes beyond what the runtime can ever allocate
+	defer func() {
+		if recover() != nil {
+			err = bytes.ErrTooLarge
+		}
+	}()
+	data := make([]byte, size, newCap)
+	copy(data, b.data)
+	b.data = data
+	return nil
+}
+
+// Seek sets the position of the next write, interpreted according to whence
+// as described in io.Seeker. Seeking past the end is allowed; the buffer only
+// grows once data is written there.
+func (b *ByteBuffer) Seek(offset int64, whence int) (int64, error) {
+	var base int64
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		base = b.pos
+	case io.SeekEnd:
+		base = int64(len(b.data))
+	default:
+		return b.pos, fmt.Errorf("ByteBuffer.Seek: invalid whence %d", whence)
+	}
+	newPos := base + offset
+	if newPos < 0 {
+		return b.pos, fmt.Errorf("ByteBuffer.Seek(%d, %d): %w", offset, whence, ErrNegativePosition)
+	}
+	b.pos = newPos
+	return newPos, nil
+}
+
+// Pos returns the position of the next write.
+func (b *ByteBuffer) Pos() int64 {
+	return b.pos
+}
+
+// Len returns the number of bytes in the buffer.
+func (b *ByteBuffer) Len() int {
+	return len(b.data)
+}
+
+// Bytes returns the contents of the buffer. The slice aliases the buffer
+// storage and is only valid until the next write.
+func (b *ByteBuffer) Bytes() []byte {
+	return b.data
+}
+
+// A ByteBufferWriter is a Writer backed by a ByteBuffer, which makes it
+// possible to seek while serializing into memory. Seek and Pos are provided
+// by the embedded Writer.
+type ByteBufferWriter struct {
+	*Writer
+	buf *ByteBuffer
+}
+
+// NewByteBufferWriter creates a Writer backed by a new, empty ByteBuffer.
+func NewByteBufferWriter() *ByteBufferWriter {
+	buf := NewByteBuffer()
+	return &ByteBufferWriter{NewWriter(buf), buf}
+}
+
+// Len returns the number of bytes written so far, including any zero-filled
+// gaps.
+func (w *ByteBufferWriter) Len() int {
+	return w.buf.Len()
+}
+
+// Bytes returns the written data. The slice aliases the buffer storage and is
+// only valid until the next write.
+func (w *ByteBufferWriter) Bytes() []byte {
+	return w.buf.Bytes()
+}
+
+// ToStream returns a new Stream for reading a copy of the written data.
+// Later writes don't affect the returned Stream.
+func (w *ByteBufferWriter) ToStream() *Stream {
+	return NewStream(bytes.NewReader(bytes.Clone(w.buf.Bytes())))
+}
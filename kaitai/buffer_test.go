@@ -0,0 +1,139 @@
+package kaitai
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestByteBuffer_Write(t *testing.T) {
+	type write struct {
+		offset int64
+		whence int
+		data   []byte
+	}
+	tests := []struct {
+		name   string
+		writes []write
+		want   []byte
+	}{
+		{"sequential", []write{{0, io.SeekCurrent, []byte{1, 2}}, {0, io.SeekCurrent, []byte{3}}}, []byte{1, 2, 3}},
+		{"overwrite", []write{{0, io.SeekStart, []byte{1, 2, 3}}, {1, io.SeekStart, []byte{9}}}, []byte{1, 9, 3}},
+		{"overwrite and extend", []write{{0, io.SeekStart, []byte{1, 2}}, {-1, io.SeekEnd, []byte{8, 9}}}, []byte{1, 8, 9}},
+		{"zero-extend", []write{{3, io.SeekStart, []byte{1}}}, []byte{0, 0, 0, 1}},
+		{"zero-extend after data", []write{{0, io.SeekStart, []byte{1}}, {2, io.SeekCurrent, []byte{2}}}, []byte{1, 0, 0, 2}},
+		{"seek without write", []write{{0, io.SeekStart, []byte{1}}, {5, io.SeekStart, nil}}, []byte{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewByteBuffer()
+			for _, w := range tt.writes {
+				if _, err := b.Seek(w.offset, w.whence); err != nil {
+					t.Fatalf("ByteBuffer.Seek() error = %v", err)
+				}
+				if _, err := b.Write(w.data); err != nil {
+					t.Fatalf("ByteBuffer.Write() error = %v", err)
+				}
+			}
+			if got := b.Bytes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ByteBuffer.Bytes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestByteBuffer_Write_tooLarge(t *testing.T) {
+	for _, pos := range []int64{1 << 62, math.MaxInt64} {
+		b := NewByteBuffer()
+		if _, err := b.Write([]byte{1}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.Seek(pos, io.SeekStart); err != nil {
+			t.Fatalf("ByteBuffer.Seek(%d) error = %v", pos, err)
+		}
+		n, err := b.Write([]byte{1, 2})
+		if !errors.Is(err, bytes.ErrTooLarge) {
+			t.Errorf("ByteBuffer.Write() at pos %d error = %v, want %v", pos, err, bytes.ErrTooLarge)
+		}
+		if n != 0 || b.Len() != 1 || b.Pos() != pos {
+			t.Errorf("ByteBuffer.Write() at pos %d = %d, Len() = %d, Pos() = %d, want 0, 1, %d", pos, n, b.Len(), b.Pos(), pos)
+		}
+	}
+}
+
+func TestByteBuffer_Seek(t *testing.T) {
+	b := NewByteBuffer()
+	if _, err := b.Write([]byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		offset  int64
+		whence  int
+		want    int64
+		wantErr error
+	}{
+		{"start", 1, io.SeekStart, 1, nil},
+		{"current", 1, io.SeekCurrent, 2, nil},
+		{"end", -3, io.SeekEnd, 0, nil},
+		{"past end", 10, io.SeekEnd, 13, nil},
+		{"negative", -1, io.SeekStart, 13, ErrNegativePosition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.Seek(tt.offset, tt.whence)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ByteBuffer.Seek() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ByteBuffer.Seek() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestByteBufferWriter_roundTrip(t *testing.T) {
+	w := NewByteBufferWriter()
+	if err := w.WriteU4le(0); err != nil { // placeholder for the length
+		t.Fatal(err)
+	}
+	if err := w.WriteBytes([]byte("payload")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteU4le(7); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := w.Pos(); err != nil || got != 4 {
+		t.Errorf("ByteBufferWriter.Pos() = %v, want %v", got, 4)
+	}
+	if got := w.Len(); got != 11 {
+		t.Errorf("ByteBufferWriter.Len() = %v, want %v", got, 11)
+	}
+
+	s := w.ToStream()
+	// Writes made after ToStream must not be visible to the stream
+	if err := w.WriteU1(0xff); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := s.ReadU4le()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 7 {
+		t.Errorf("Stream.ReadU4le() = %v, want %v", n, 7)
+	}
+	payload, err := s.ReadBytes(int(n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(payload) != "payload" {
+		t.Errorf("Stream.ReadBytes() = %q, want %q", payload, "payload")
+	}
+}
@@ -1,14 +1,43 @@
 package kaitai
 
-import "fmt"
-
-// EndOfStreamError is returned when the stream unexpectedly ends.
-type EndOfStreamError struct{}
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EndOfStreamError is returned when the stream unexpectedly ends. It matches
+// io.ErrUnexpectedEOF with errors.Is, even if the read ended right at the end
+// of the stream, which allows telling truncated data apart from I/O errors.
+type EndOfStreamError struct {
+	// Method is the name of the Stream method that failed.
+	Method string
+	// Pos is the position at which the read started, or -1 if unknown.
+	Pos int64
+	// Requested is the number of bytes needed. For reads up to a terminator,
+	// it counts the terminator as the only byte needed beyond the available
+	// ones.
+	Requested int
+	// Available is the number of bytes that were left in the stream.
+	Available int
+	// Err is the error of the underlying read, io.EOF if no bytes were left
+	// and io.ErrUnexpectedEOF otherwise.
+	Err error
+}
 
-func (EndOfStreamError) Error() string {
-	return "unexpected end of stream"
+func (e EndOfStreamError) Error() string {
+	if e.Method == "" {
+		return "unexpected end of stream"
+	}
+	return fmt.Sprintf("%s: unexpected end of stream at pos %d: requested %d bytes, %d available", e.Method, e.Pos, e.Requested, e.Available)
 }
 
+// Is reports whether target is io.ErrUnexpectedEOF.
+func (e EndOfStreamError) Is(target error) bool { return target == io.ErrUnexpectedEOF }
+
+func (e EndOfStreamError) Unwrap() error { return e.Err }
+
 // UndecidedEndiannessError occurs when a value has calculated or inherited
 // endianness, and the endianness could not be determined.
 type UndecidedEndiannessError struct{}
@@ -17,36 +46,299 @@ func (UndecidedEndiannessError) Error() string {
 	return "undecided endianness"
 }
 
+// WriteError is returned by Writer methods when the underlying writer fails
+// or writes fewer bytes than requested.
+type WriteError struct {
+	// Method is the name of the Writer method that failed.
+	Method string
+	// Offset is the position at which the write started.
+	Offset    int64
+	Requested int
+	Written   int
+	// Err is the error returned by the underlying writer, which may be nil
+	// for a short write.
+	Err error
+}
+
+func (e WriteError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s: short write at offset %d: wrote %d of %d bytes", e.Method, e.Offset, e.Written, e.Requested)
+	}
+	return fmt.Sprintf("%s: error writing %d bytes at offset %d (wrote %d): %v", e.Method, e.Requested, e.Offset, e.Written, e.Err)
+}
+
+// Unwrap returns the error of the underlying writer, or io.ErrShortWrite if
+// it reported none.
+func (e WriteError) Unwrap() error {
+	if e.Err == nil {
+		return io.ErrShortWrite
+	}
+	return e.Err
+}
+
+// UnencodableRuneError occurs when a string contains a rune that cannot be
+// represented in the target encoding.
+type UnencodableRuneError struct {
+	// Index is the position of the rune in the string, counted in runes.
+	Index int
+	Rune  rune
+	Err   error
+}
+
+func (e UnencodableRuneError) Error() string {
+	return fmt.Sprintf("cannot encode rune %q (%U) at rune index %d", e.Rune, e.Rune, e.Index)
+}
+
+func (e UnencodableRuneError) Unwrap() error { return e.Err }
+
+// TextDecodeError is returned by the process functions for text encodings,
+// such as ProcessHex and ProcessBase64, when their input is invalid.
+type TextDecodeError struct {
+	// Encoding is the name of the text encoding, e.g. "base64".
+	Encoding string
+	// Offset is the position of the first invalid character within the
+	// processed data. For truncated input, it is the length of the data.
+	Offset int64
+	// Err describes the problem in more detail, if available.
+	Err error
+}
+
+func (e TextDecodeError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("invalid %s data at offset %d", e.Encoding, e.Offset)
+	}
+	return fmt.Sprintf("invalid %s data at offset %d: %v", e.Encoding, e.Offset, e.Err)
+}
+
+func (e TextDecodeError) Unwrap() error { return e.Err }
+
+// PanicError is returned by SafeParse when the parsing function panics, e.g.
+// on an index out of range or a division by zero in an expression.
+type PanicError struct {
+	// Value is the value the function panicked with.
+	Value interface{}
+	// Pos is the position of the stream at the time of the panic, or -1 if
+	// it's unknown.
+	Pos int64
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e PanicError) Error() string {
+	if e.Pos < 0 {
+		return fmt.Sprintf("panic during parsing: %v", e.Value)
+	}
+	return fmt.Sprintf("panic during parsing at pos %d: %v", e.Pos, e.Value)
+}
+
+// Unwrap returns Value if it's an error, such as a runtime.Error, and nil
+// otherwise.
+func (e PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
 type locationInfo struct {
 	io      *Stream
 	srcPath string
+	// Position in io when the error was created, -1 if unknown
+	pos int64
+	// Bytes of io around pos and the position of the first one, captured
+	// if enabled by Stream.SetErrorContext
+	windowStart int64
+	window      []byte
 }
 
+// newLocationInfo captures the current position of io, so that it's reported
+// correctly even if io is used or closed before the error is handled.
 func newLocationInfo(io *Stream, srcPath string) locationInfo {
-	return locationInfo{
-		io,
-		srcPath,
+	l := locationInfo{io: io, srcPath: srcPath, pos: -1}
+	if io == nil {
+		return l
+	}
+	if p, err := io.Pos(); err == nil {
+		l.pos = p
+		if io.errorContext > 0 {
+			l.windowStart, l.window = io.window(p, io.errorContext)
+		}
 	}
+	return l
 }
 
 func (l locationInfo) Io() *Stream { return l.io }
 
 func (l locationInfo) SrcPath() string { return l.srcPath }
 
+// Pos returns the position of the stream at the time the error was created,
+// or -1 if it's unknown.
+func (l locationInfo) Pos() int64 { return l.pos }
+
+// Window returns the bytes of the stream surrounding Pos, captured when the
+// error was created, and the position of the first one. It returns nil
+// unless enabled by Stream.SetErrorContext.
+func (l locationInfo) Window() (int64, []byte) { return l.windowStart, l.window }
+
 func (l locationInfo) msgWithLocation(msg string) string {
-	var pos interface{}
-	pos, err := l.io.Pos()
-	if err != nil {
-		pos = "N/A"
+	var pos interface{} = "N/A"
+	if l.pos >= 0 {
+		pos = l.pos
 	}
 	return fmt.Sprintf("%s: at pos %v: %s", l.srcPath, pos, msg)
 }
 
+// ProcessError signals that a process routine failed on the data of a field.
+type ProcessError struct {
+	name string
+	err  error
+	locationInfo
+}
+
+// NewProcessError creates a new ProcessError instance. io may be nil if the
+// error occurred during serialization.
+func NewProcessError(name string, err error, io *Stream, srcPath string) ProcessError {
+	return ProcessError{
+		name,
+		err,
+		newLocationInfo(io, srcPath),
+	}
+}
+
+// Name is a getter of the name of the process which failed.
+func (e ProcessError) Name() string { return e.name }
+
+func (e ProcessError) Error() string {
+	return e.msgWithLocation(fmt.Sprintf("process %s failed: %v", e.name, e.err))
+}
+
+func (e ProcessError) Unwrap() error { return e.err }
+
+// ParseFrame is one level of the object tree in a ParseError.
+type ParseFrame struct {
+	// Type is the name of the type being parsed.
+	Type string
+	// Pos is the position in the stream at which parsing of the type
+	// started.
+	Pos int64
+	// Field is the name of the field being parsed when the error occurred.
+	Field string
+	// Index is the index of the element within a repeated field, or -1 if
+	// the field is not repeated.
+	Index int
+}
+
+// ParseError records where in the object tree parsing failed. It is built up
+// by WrapParseError and WrapParseErrorIndex as the error propagates from the
+// innermost Read method to the root, each level adding a frame. The error
+// which started the propagation is available through Unwrap.
+type ParseError struct {
+	// Frames lists the levels of the object tree, starting from the root.
+	Frames []ParseFrame
+	Err    error
+}
+
+// WrapParseError adds a frame to err for the non-repeated field being parsed
+// in the type typeName, whose parsing started at pos. If err is a
+// ParseError, the frame is prepended to its frames, otherwise a new
+// ParseError wrapping err is returned. A nil err is returned unchanged.
+//
+// If a ParseError is wrapped by other errors, e.g. with fmt.Errorf, its
+// frames are taken over as well, and the messages added by the wrapping
+// errors are kept in front of the error it wraps.
+func WrapParseError(err error, typeName string, pos int64, field string) error {
+	return WrapParseErrorIndex(err, typeName, pos, field, -1)
+}
+
+// WrapParseErrorIndex is like WrapParseError, but for the element with the
+// given index of a repeated field.
+func WrapParseErrorIndex(err error, typeName string, pos int64, field string, index int) error {
+	if err == nil {
+		return nil
+	}
+	frame := ParseFrame{typeName, pos, field, index}
+	var e ParseError
+	if !errors.As(err, &e) {
+		return ParseError{[]ParseFrame{frame}, err}
+	}
+	if _, ok := err.(ParseError); !ok {
+		// Only merge if the message of the nested ParseError can be told
+		// apart from the context added around it
+		prefix, found := strings.CutSuffix(err.Error(), e.Error())
+		if !found {
+			return ParseError{[]ParseFrame{frame}, err}
+		}
+		e.Err = parseContextError{prefix, e.Err}
+	}
+	frames := make([]ParseFrame, 0, len(e.Frames)+1)
+	e.Frames = append(append(frames, frame), e.Frames...)
+	return e
+}
+
+// parseContextError keeps the context added by errors which wrapped a nested
+// ParseError, such as "reading entry: ", in front of the error the nested
+// ParseError wrapped.
+type parseContextError struct {
+	prefix string
+	err    error
+}
+
+func (e parseContextError) Error() string { return e.prefix + e.err.Error() }
+
+func (e parseContextError) Unwrap() error { return e.err }
+
+// Path returns the path of the field being parsed, relative to the root,
+// such as "header.entries[3].name".
+func (e ParseError) Path() string {
+	var b strings.Builder
+	for i, f := range e.Frames {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(f.Field)
+		if f.Index >= 0 {
+			fmt.Fprintf(&b, "[%d]", f.Index)
+		}
+	}
+	return b.String()
+}
+
+func (e ParseError) Error() string {
+	if len(e.Frames) == 0 {
+		return fmt.Sprintf("parse error: %v", e.Err)
+	}
+	root, inner := e.Frames[0], e.Frames[len(e.Frames)-1]
+	return fmt.Sprintf("%s.%s: error parsing %s at pos %d: %v", root.Type, e.Path(), inner.Type, inner.Pos, e.Err)
+}
+
+func (e ParseError) Unwrap() error { return e.Err }
+
 // ValidationFailedError is an interface that all "Validation*Error"s implement.
 type ValidationFailedError interface {
 	Actual() interface{}
 	Io() *Stream
 	SrcPath() string
+	// Pos returns the position of Io at the time the error was created, or
+	// -1 if it's unknown.
+	Pos() int64
+}
+
+// Sentinel errors matched by the Validation*Error types with errors.Is.
+// Every validation error matches ErrValidationFailed, as well as the sentinel
+// of its own kind.
+var (
+	ErrValidationFailed = errors.New("validation failed")
+	ErrNotEqual         = errors.New("not equal")
+	ErrLessThan         = errors.New("less than minimum")
+	ErrGreaterThan      = errors.New("greater than maximum")
+	ErrNotAnyOf         = errors.New("not any of the list")
+	ErrNotInEnum        = errors.New("not in the enum")
+	ErrExpr             = errors.New("expression not satisfied")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrBytesMismatch    = errors.New("bytes mismatch")
+)
+
+// isValidation reports whether target is ErrValidationFailed or kind.
+func isValidation(target, kind error) bool {
+	return target == ErrValidationFailed || target == kind
 }
 
 func validationFailedMsg(msg string) string {
@@ -80,11 +372,14 @@ func (e ValidationNotEqualError) Actual() interface{} { return e.actual }
 func (e ValidationNotEqualError) Error() string {
 	return e.msgWithLocation(
 		validationFailedMsg(
-			fmt.Sprintf("not equal, expected %v, but got %v", e.expected, e.actual),
+			"not equal, " + formatComparison(e.expected, e.actual),
 		),
 	)
 }
 
+// Is reports whether target is ErrValidationFailed or ErrNotEqual.
+func (e ValidationNotEqualError) Is(target error) bool { return isValidation(target, ErrNotEqual) }
+
 // ValidationLessThanError signals validation failure: we required "Actual" value
 // to be greater than or equal to "Min", but it turned out that it's not.
 type ValidationLessThanError struct {
@@ -112,11 +407,14 @@ func (e ValidationLessThanError) Actual() interface{} { return e.actual }
 func (e ValidationLessThanError) Error() string {
 	return e.msgWithLocation(
 		validationFailedMsg(
-			fmt.Sprintf("not in range, min %v, but got %v", e.min, e.actual),
+			fmt.Sprintf("not in range, min %s, but got %s", formatValue(e.min), formatValue(e.actual)),
 		),
 	)
 }
 
+// Is reports whether target is ErrValidationFailed or ErrLessThan.
+func (e ValidationLessThanError) Is(target error) bool { return isValidation(target, ErrLessThan) }
+
 // ValidationGreaterThanError signals validation failure: we required "Actual" value
 // to be less than or equal to "Max", but it turned out that it's not.
 type ValidationGreaterThanError struct {
@@ -144,11 +442,16 @@ func (e ValidationGreaterThanError) Actual() interface{} { return e.actual }
 func (e ValidationGreaterThanError) Error() string {
 	return e.msgWithLocation(
 		validationFailedMsg(
-			fmt.Sprintf("not in range, max %v, but got %v", e.max, e.actual),
+			fmt.Sprintf("not in range, max %s, but got %s", formatValue(e.max), formatValue(e.actual)),
 		),
 	)
 }
 
+// Is reports whether target is ErrValidationFailed or ErrGreaterThan.
+func (e ValidationGreaterThanError) Is(target error) bool {
+	return isValidation(target, ErrGreaterThan)
+}
+
 // ValidationNotAnyOfError signals validation failure: we required "Actual" value
 // to be from the list, but it turned out that it's not.
 type ValidationNotAnyOfError struct {
@@ -170,11 +473,14 @@ func (e ValidationNotAnyOfError) Actual() interface{} { return e.actual }
 func (e ValidationNotAnyOfError) Error() string {
 	return e.msgWithLocation(
 		validationFailedMsg(
-			fmt.Sprintf("not any of the list, got %v", e.actual),
+			"not any of the list, got " + formatValue(e.actual),
 		),
 	)
 }
 
+// Is reports whether target is ErrValidationFailed or ErrNotAnyOf.
+func (e ValidationNotAnyOfError) Is(target error) bool { return isValidation(target, ErrNotAnyOf) }
+
 // ValidationNotInEnumError signals validation failure: we required "Actual" value
 // to be in the enum, but it turned out that it's not.
 type ValidationNotInEnumError struct {
@@ -196,11 +502,14 @@ func (e ValidationNotInEnumError) Actual() interface{} { return e.actual }
 func (e ValidationNotInEnumError) Error() string {
 	return e.msgWithLocation(
 		validationFailedMsg(
-			fmt.Sprintf("not in the enum, got %v", e.actual),
+			"not in the enum, got " + formatValue(e.actual),
 		),
 	)
 }
 
+// Is reports whether target is ErrValidationFailed or ErrNotInEnum.
+func (e ValidationNotInEnumError) Is(target error) bool { return isValidation(target, ErrNotInEnum) }
+
 // ValidationExprError signals validation failure: we required "Actual" value
 // to match the expression, but it turned out that it doesn't.
 type ValidationExprError struct {
@@ -222,7 +531,225 @@ func (e ValidationExprError) Actual() interface{} { return e.actual }
 func (e ValidationExprError) Error() string {
 	return e.msgWithLocation(
 		validationFailedMsg(
-			fmt.Sprintf("not matching the expression, got %v", e.actual),
+			"not matching the expression, got " + formatValue(e.actual),
+		),
+	)
+}
+
+// Is reports whether target is ErrValidationFailed or ErrExpr.
+func (e ValidationExprError) Is(target error) bool { return isValidation(target, ErrExpr) }
+
+// ValidationChecksumError signals validation failure: the checksum stored
+// in the data ("Actual") differs from the one computed over the covered
+// range of the stream ("Computed").
+type ValidationChecksumError struct {
+	algorithm  string
+	start, end int64
+	stored     interface{}
+	computed   interface{}
+	locationInfo
+}
+
+// NewValidationChecksumError creates a new ValidationChecksumError instance.
+// The checksum computed with algorithm, such as "crc32", covers the bytes
+// from start up to, but not including, end.
+func NewValidationChecksumError(
+	algorithm string, start, end int64, stored, computed interface{}, io *Stream, srcPath string) ValidationChecksumError {
+	return ValidationChecksumError{
+		algorithm,
+		start,
+		end,
+		stored,
+		computed,
+		newLocationInfo(io, srcPath),
+	}
+}
+
+// Algorithm is a getter of the name of the checksum algorithm.
+func (e ValidationChecksumError) Algorithm() string { return e.algorithm }
+
+// Range is a getter of the range covered by the checksum, from start up to,
+// but not including, end.
+func (e ValidationChecksumError) Range() (start, end int64) { return e.start, e.end }
+
+// Stored is a getter of the checksum stored in the data, the same as Actual.
+func (e ValidationChecksumError) Stored() interface{} { return e.stored }
+
+// Computed is a getter of the checksum computed over the covered range.
+func (e ValidationChecksumError) Computed() interface{} { return e.computed }
+
+// Actual is a getter of the actual value associated with the validation error.
+func (e ValidationChecksumError) Actual() interface{} { return e.stored }
+
+func (e ValidationChecksumError) Error() string {
+	return e.msgWithLocation(
+		validationFailedMsg(
+			fmt.Sprintf("%s mismatch over bytes [%d, %d), computed %s, but stored %s",
+				e.algorithm, e.start, e.end, formatValue(e.computed), formatValue(e.stored)),
 		),
 	)
 }
+
+// Is reports whether target is ErrValidationFailed or ErrChecksumMismatch.
+func (e ValidationChecksumError) Is(target error) bool {
+	return isValidation(target, ErrChecksumMismatch)
+}
+
+// ValidationBytesMismatchError signals validation failure: we required the
+// "Actual" bytes to be equal to the "Expected" ones, such as a magic
+// signature, but they differ at "Offset".
+type ValidationBytesMismatchError struct {
+	expected []byte
+	actual   []byte
+	offset   int
+	locationInfo
+}
+
+// NewValidationBytesMismatchError creates a new ValidationBytesMismatchError
+// instance.
+func NewValidationBytesMismatchError(expected, actual []byte, io *Stream, srcPath string) ValidationBytesMismatchError {
+	return ValidationBytesMismatchError{
+		expected,
+		actual,
+		mismatchOffset(expected, actual),
+		newLocationInfo(io, srcPath),
+	}
+}
+
+// Expected is a getter of the expected bytes.
+func (e ValidationBytesMismatchError) Expected() []byte { return e.expected }
+
+// Actual is a getter of the actual value associated with the validation error.
+func (e ValidationBytesMismatchError) Actual() interface{} { return e.actual }
+
+// Offset is a getter of the offset of the first mismatching byte. If one of
+// the byte slices is a prefix of the other, it's the length of the shorter
+// one.
+func (e ValidationBytesMismatchError) Offset() int { return e.offset }
+
+// ExpectedWindow returns the part of the expected bytes around Offset which
+// is shown in the error message, and its offset.
+func (e ValidationBytesMismatchError) ExpectedWindow() (int, []byte) {
+	return byteWindow(e.expected, e.offset-mismatchContext)
+}
+
+// ActualWindow returns the part of the actual bytes around Offset which is
+// shown in the error message, and its offset.
+func (e ValidationBytesMismatchError) ActualWindow() (int, []byte) {
+	return byteWindow(e.actual, e.offset-mismatchContext)
+}
+
+func (e ValidationBytesMismatchError) Error() string {
+	from := e.offset - mismatchContext
+	return e.msgWithLocation(
+		validationFailedMsg(
+			fmt.Sprintf("bytes mismatch at offset %d, expected %s, but got %s",
+				e.offset, formatBytes(e.expected, from), formatBytes(e.actual, from)),
+		),
+	)
+}
+
+// Is reports whether target is ErrValidationFailed or ErrBytesMismatch.
+func (e ValidationBytesMismatchError) Is(target error) bool {
+	return isValidation(target, ErrBytesMismatch)
+}
+
+// ConsistencyFailedError is an interface that all "Consistency*Error"s
+// implement. These errors are returned during serialization when the fields
+// of a struct contradict each other.
+type ConsistencyFailedError interface {
+	Expected() interface{}
+	Actual() interface{}
+	SrcPath() string
+}
+
+type consistencyInfo struct {
+	expected interface{}
+	actual   interface{}
+	srcPath  string
+}
+
+// Expected is a getter of the expected value associated with the consistency error.
+func (c consistencyInfo) Expected() interface{} { return c.expected }
+
+// Actual is a getter of the actual value associated with the consistency error.
+func (c consistencyInfo) Actual() interface{} { return c.actual }
+
+func (c consistencyInfo) SrcPath() string { return c.srcPath }
+
+func (c consistencyInfo) msgWithPath(msg string) string {
+	return fmt.Sprintf("%s: consistency check failed: %s", c.srcPath, msg)
+}
+
+// ConsistencyError signals that the "Actual" value of a field doesn't match
+// the "Expected" value derived from other fields.
+type ConsistencyError struct {
+	consistencyInfo
+}
+
+// NewConsistencyError creates a new ConsistencyError instance.
+func NewConsistencyError(expected interface{}, actual interface{}, srcPath string) ConsistencyError {
+	return ConsistencyError{consistencyInfo{expected, actual, srcPath}}
+}
+
+func (e ConsistencyError) Error() string {
+	return e.msgWithPath(formatComparison(e.expected, e.actual))
+}
+
+// ConsistencyCountMismatchError signals that the number of elements of an
+// array ("Actual") disagrees with the number given by its count field
+// ("Expected").
+type ConsistencyCountMismatchError struct {
+	consistencyInfo
+}
+
+// NewConsistencyCountMismatchError creates a new ConsistencyCountMismatchError instance.
+func NewConsistencyCountMismatchError(expected interface{}, actual interface{}, srcPath string) ConsistencyCountMismatchError {
+	return ConsistencyCountMismatchError{consistencyInfo{expected, actual, srcPath}}
+}
+
+func (e ConsistencyCountMismatchError) Error() string {
+	return e.msgWithPath(fmt.Sprintf("count mismatch, expected %v elements, but got %v", e.expected, e.actual))
+}
+
+// ConsistencySizeMismatchError signals that the length of a byte array
+// ("Actual") disagrees with its size ("Expected").
+type ConsistencySizeMismatchError struct {
+	consistencyInfo
+}
+
+// NewConsistencySizeMismatchError creates a new ConsistencySizeMismatchError instance.
+func NewConsistencySizeMismatchError(expected interface{}, actual interface{}, srcPath string) ConsistencySizeMismatchError {
+	return ConsistencySizeMismatchError{consistencyInfo{expected, actual, srcPath}}
+}
+
+func (e ConsistencySizeMismatchError) Error() string {
+	return e.msgWithPath(fmt.Sprintf("size mismatch, expected %v bytes, but got %v", e.expected, e.actual))
+}
+
+// ConsistencyTerminatorError signals that the terminator of a field occurs
+// inside its data. "Expected" is the length of the data, i.e. the only index
+// the terminator may be written at, and "Actual" is the index where it was
+// found.
+type ConsistencyTerminatorError struct {
+	term interface{}
+	consistencyInfo
+}
+
+// NewConsistencyTerminatorError creates a new ConsistencyTerminatorError instance.
+func NewConsistencyTerminatorError(
+	term interface{}, expected interface{}, actual interface{}, srcPath string) ConsistencyTerminatorError {
+	return ConsistencyTerminatorError{
+		term,
+		consistencyInfo{expected, actual, srcPath},
+	}
+}
+
+// Term is a getter of the terminator associated with the consistency error.
+func (e ConsistencyTerminatorError) Term() interface{} { return e.term }
+
+func (e ConsistencyTerminatorError) Error() string {
+	return e.msgWithPath(
+		fmt.Sprintf("terminator %s found inside the data at index %v, expected only at index %v", formatValue(e.term), e.actual, e.expected),
+	)
+}
@@ -0,0 +1,113 @@
+package kaitai
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxFormattedBytes is the number of bytes of a byte slice shown in error
+// messages. Longer slices are truncated.
+const maxFormattedBytes = 32
+
+// formatValue formats a value for an error message. Byte slices are shown as
+// hex bytes followed by their printable ASCII characters, and integers both
+// in decimal and hex. Other values are formatted with %v.
+func formatValue(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return formatBytes(b, 0)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := rv.Int()
+		if i < 0 {
+			return fmt.Sprintf("%d (-0x%x)", i, uint64(-i))
+		}
+		return fmt.Sprintf("%d (0x%x)", i, i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprintf("%d (0x%x)", rv.Uint(), rv.Uint())
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// formatBytes formats b like formatValue. If b is too long to be shown
+// completely, the shown part starts as close to offset from as possible and
+// the total length is added.
+func formatBytes(b []byte, from int) string {
+	if len(b) == 0 {
+		return "(empty)"
+	}
+	start, w := byteWindow(b, from)
+	end := start + len(w)
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("... ")
+	}
+	for i, c := range w {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		fmt.Fprintf(&sb, "%02x", c)
+	}
+	if end < len(b) {
+		sb.WriteString(" ...")
+	}
+	sb.WriteString(" |")
+	for _, c := range w {
+		if c < 0x20 || c > 0x7e {
+			c = '.'
+		}
+		sb.WriteByte(c)
+	}
+	sb.WriteByte('|')
+	if start > 0 || end < len(b) {
+		fmt.Fprintf(&sb, " (%d bytes, showing offsets %d-%d)", len(b), start, end-1)
+	}
+	return sb.String()
+}
+
+// byteWindow returns the part of b shown in error messages, which is all of
+// b if it's short enough, and its offset in b. Otherwise, it starts as close
+// to offset from as possible.
+func byteWindow(b []byte, from int) (int, []byte) {
+	if len(b) <= maxFormattedBytes {
+		return 0, b
+	}
+	start := min(max(from, 0), len(b)-maxFormattedBytes)
+	return start, b[start : start+maxFormattedBytes]
+}
+
+// mismatchOffset returns the offset of the first byte differing between a
+// and b, which is the length of the shorter one if it's a prefix of the
+// other.
+func mismatchOffset(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// mismatchContext is the number of matching bytes shown before the first
+// difference of two byte slices.
+const mismatchContext = maxFormattedBytes / 4
+
+// formatComparison formats expected and actual values for an error message
+// as "expected X, but got Y". If both are byte slices, the offset of the
+// first difference is added, and long slices are shown around it.
+func formatComparison(expected, actual interface{}) string {
+	eb, ok1 := expected.([]byte)
+	ab, ok2 := actual.([]byte)
+	if !ok1 || !ok2 {
+		return fmt.Sprintf("expected %s, but got %s", formatValue(expected), formatValue(actual))
+	}
+	diff := mismatchOffset(eb, ab)
+	from := diff - mismatchContext
+	msg := fmt.Sprintf("expected %s, but got %s", formatBytes(eb, from), formatBytes(ab, from))
+	if diff < len(eb) || diff < len(ab) {
+		msg += fmt.Sprintf(", first difference at offset %d", diff)
+	}
+	return msg
+}
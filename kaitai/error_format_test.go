@@ -0,0 +1,69 @@
+package kaitai
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_formatValue(t *testing.T) {
+	type enum int32
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"int", 255, "255 (0xff)"},
+		{"negative int", int8(-16), "-16 (-0x10)"},
+		{"uint64", uint64(1) << 63, "9223372036854775808 (0x8000000000000000)"},
+		{"enum", enum(3), "3 (0x3)"},
+		{"string", "abc", "abc"},
+		{"float", 1.5, "1.5"},
+		{"PNG magic", []byte{0x89, 'P', 'N', 'G'}, "89 50 4e 47 |.PNG|"},
+		{"empty bytes", []byte{}, "(empty)"},
+		{
+			"long bytes", bytes.Repeat([]byte{'a'}, 40),
+			"61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 61 ..." +
+				" |aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa| (40 bytes, showing offsets 0-31)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatValue(tt.v); got != tt.want {
+				t.Errorf("formatValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatComparison(t *testing.T) {
+	long := bytes.Repeat([]byte{0}, 100)
+	longDiff := bytes.Clone(long)
+	longDiff[60] = 'x'
+	tests := []struct {
+		name             string
+		expected, actual interface{}
+		want             string
+	}{
+		{"ints", 1, 2, "expected 1 (0x1), but got 2 (0x2)"},
+		{"bytes", []byte("\x89PNG"), []byte("\x89PNx"), "expected 89 50 4e 47 |.PNG|, but got 89 50 4e 78 |.PNx|, first difference at offset 3"},
+		{"prefix", []byte("ab"), []byte("a"), "expected 61 62 |ab|, but got 61 |a|, first difference at offset 1"},
+		{
+			"long bytes", long, longDiff,
+			"expected ... " + hexZeros(32) + " ... |" + string(bytes.Repeat([]byte{'.'}, 32)) + "| (100 bytes, showing offsets 52-83)" +
+				", but got ... " + hexZeros(8) + " 78 " + hexZeros(23) + " ... |........x" + string(bytes.Repeat([]byte{'.'}, 23)) +
+				"| (100 bytes, showing offsets 52-83), first difference at offset 60",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatComparison(tt.expected, tt.actual); got != tt.want {
+				t.Errorf("formatComparison() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// hexZeros returns n zero bytes formatted like formatBytes does.
+func hexZeros(n int) string {
+	return string(bytes.TrimSuffix(bytes.Repeat([]byte("00 "), n), []byte(" ")))
+}
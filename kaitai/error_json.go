@@ -0,0 +1,287 @@
+package kaitai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// errorJSON is the JSON representation shared by all error types of the
+// runtime. Kind is the name of the error type. Values are marshaled as JSON
+// if possible, which turns byte slices into base64, and as their %v string
+// otherwise. Fields specific to a single error type are put in Details.
+// Cause is the JSON representation of the wrapped error, if any, so that
+// nested causes form a chain.
+type errorJSON struct {
+	Kind     string                 `json:"kind"`
+	Message  string                 `json:"message"`
+	SrcPath  string                 `json:"srcPath,omitempty"`
+	Pos      *int64                 `json:"pos,omitempty"`
+	Expected json.RawMessage        `json:"expected,omitempty"`
+	Actual   json.RawMessage        `json:"actual,omitempty"`
+	Min      json.RawMessage        `json:"min,omitempty"`
+	Max      json.RawMessage        `json:"max,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+	Cause    json.RawMessage        `json:"cause,omitempty"`
+}
+
+// newErrorJSON starts the JSON representation of err, including the chain of
+// errors it wraps.
+func newErrorJSON(kind string, err error) *errorJSON {
+	return &errorJSON{Kind: kind, Message: err.Error(), Cause: causeJSON(errors.Unwrap(err))}
+}
+
+func (j *errorJSON) marshal() ([]byte, error) {
+	return json.Marshal(j)
+}
+
+// withLocation adds the source path and position from l.
+func (j *errorJSON) withLocation(l locationInfo) *errorJSON {
+	j.SrcPath = l.srcPath
+	if l.pos >= 0 {
+		pos := l.pos
+		j.Pos = &pos
+	}
+	if l.window != nil {
+		j.detail("windowStart", l.windowStart)
+		j.detail("window", l.window)
+	}
+	return j
+}
+
+func (j *errorJSON) detail(key string, v interface{}) *errorJSON {
+	if j.Details == nil {
+		j.Details = make(map[string]interface{})
+	}
+	j.Details[key] = v
+	return j
+}
+
+// valueJSON marshals v, falling back to its %v string for values that can't
+// be represented in JSON.
+func valueJSON(v interface{}) json.RawMessage {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprintf("%v", v))
+	}
+	return b
+}
+
+// causeJSON returns the JSON representation of err, which may be an error of
+// any package, or nil if err is nil.
+func causeJSON(err error) json.RawMessage {
+	if err == nil {
+		return nil
+	}
+	if m, ok := err.(json.Marshaler); ok {
+		if b, merr := m.MarshalJSON(); merr == nil {
+			return b
+		}
+	}
+	b, _ := newErrorJSON(fmt.Sprintf("%T", err), err).marshal()
+	return b
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e EndOfStreamError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("EndOfStreamError", e)
+	if e.Method != "" {
+		pos := e.Pos
+		j.Pos = &pos
+		j.detail("method", e.Method).detail("requested", e.Requested).detail("available", e.Available)
+	}
+	return j.marshal()
+}
+
+// MarshalJSON implements json.Marshaler. The panic value is reported as a
+// string, as it may not be marshalable. The stack trace is left out, as it
+// reveals internals of the program to whoever receives the JSON; use
+// MarshalJSONWithStack to include it.
+func (e PanicError) MarshalJSON() ([]byte, error) {
+	return e.errorJSON().marshal()
+}
+
+// MarshalJSONWithStack is like MarshalJSON, but includes the stack trace in
+// the "stack" detail, e.g. for logs which aren't shown to clients.
+func (e PanicError) MarshalJSONWithStack() ([]byte, error) {
+	return e.errorJSON().detail("stack", string(e.Stack)).marshal()
+}
+
+func (e PanicError) errorJSON() *errorJSON {
+	j := newErrorJSON("PanicError", e)
+	if e.Pos >= 0 {
+		pos := e.Pos
+		j.Pos = &pos
+	}
+	return j.detail("value", fmt.Sprint(e.Value))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e UndecidedEndiannessError) MarshalJSON() ([]byte, error) {
+	return newErrorJSON("UndecidedEndiannessError", e).marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e WriteError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("WriteError", e)
+	j.Pos = &e.Offset
+	j.detail("method", e.Method).detail("requested", e.Requested).detail("written", e.Written)
+	return j.marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e UnencodableRuneError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("UnencodableRuneError", e)
+	j.Actual = valueJSON(string(e.Rune))
+	j.detail("index", e.Index)
+	return j.marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e TextDecodeError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("TextDecodeError", e)
+	j.Pos = &e.Offset
+	j.detail("encoding", e.Encoding)
+	return j.marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ProcessError) MarshalJSON() ([]byte, error) {
+	return newErrorJSON("ProcessError", e).withLocation(e.locationInfo).detail("name", e.name).marshal()
+}
+
+// MarshalJSON implements json.Marshaler. The position is the one at which
+// the parsing of the innermost type started.
+func (e ParseError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("ParseError", e)
+	j.SrcPath = e.Path()
+	if len(e.Frames) > 0 {
+		pos := e.Frames[len(e.Frames)-1].Pos
+		j.Pos = &pos
+	}
+	type frameJSON struct {
+		Type  string `json:"type"`
+		Pos   int64  `json:"pos"`
+		Field string `json:"field"`
+		Index *int   `json:"index,omitempty"`
+	}
+	frames := make([]frameJSON, len(e.Frames))
+	for i, f := range e.Frames {
+		frames[i] = frameJSON{Type: f.Type, Pos: f.Pos, Field: f.Field}
+		if f.Index >= 0 {
+			index := f.Index
+			frames[i].Index = &index
+		}
+	}
+	j.detail("frames", frames)
+	return j.marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ValidationNotEqualError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("ValidationNotEqualError", e).withLocation(e.locationInfo)
+	j.Expected, j.Actual = valueJSON(e.expected), valueJSON(e.actual)
+	return j.marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ValidationLessThanError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("ValidationLessThanError", e).withLocation(e.locationInfo)
+	j.Min, j.Actual = valueJSON(e.min), valueJSON(e.actual)
+	return j.marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ValidationGreaterThanError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("ValidationGreaterThanError", e).withLocation(e.locationInfo)
+	j.Max, j.Actual = valueJSON(e.max), valueJSON(e.actual)
+	return j.marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ValidationNotAnyOfError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("ValidationNotAnyOfError", e).withLocation(e.locationInfo)
+	j.Actual = valueJSON(e.actual)
+	return j.marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ValidationNotInEnumError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("ValidationNotInEnumError", e).withLocation(e.locationInfo)
+	j.Actual = valueJSON(e.actual)
+	return j.marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ValidationExprError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("ValidationExprError", e).withLocation(e.locationInfo)
+	j.Actual = valueJSON(e.actual)
+	return j.marshal()
+}
+
+// MarshalJSON implements json.Marshaler. The computed checksum is reported
+// as the expected value.
+func (e ValidationChecksumError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("ValidationChecksumError", e).withLocation(e.locationInfo)
+	j.Expected, j.Actual = valueJSON(e.computed), valueJSON(e.stored)
+	j.detail("algorithm", e.algorithm).detail("start", e.start).detail("end", e.end)
+	return j.marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ValidationBytesMismatchError) MarshalJSON() ([]byte, error) {
+	j := newErrorJSON("ValidationBytesMismatchError", e).withLocation(e.locationInfo)
+	j.Expected, j.Actual = valueJSON(e.expected), valueJSON(e.actual)
+	j.detail("offset", e.offset)
+	return j.marshal()
+}
+
+func (c consistencyInfo) errorJSON(kind string, err error) *errorJSON {
+	j := newErrorJSON(kind, err)
+	j.SrcPath = c.srcPath
+	j.Expected, j.Actual = valueJSON(c.expected), valueJSON(c.actual)
+	return j
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ConsistencyError) MarshalJSON() ([]byte, error) {
+	return e.errorJSON("ConsistencyError", e).marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ConsistencyCountMismatchError) MarshalJSON() ([]byte, error) {
+	return e.errorJSON("ConsistencyCountMismatchError", e).marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ConsistencySizeMismatchError) MarshalJSON() ([]byte, error) {
+	return e.errorJSON("ConsistencySizeMismatchError", e).marshal()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ConsistencyTerminatorError) MarshalJSON() ([]byte, error) {
+	return e.errorJSON("ConsistencyTerminatorError", e).detail("term", valueJSON(e.term)).marshal()
+}
+
+// MarshalJSON implements json.Marshaler. The aggregated errors are listed in
+// the "errors" detail.
+func (e ValidationErrors) MarshalJSON() ([]byte, error) {
+	errs := make([]json.RawMessage, len(e))
+	for i, err := range e {
+		errs[i] = causeJSON(err)
+	}
+	return newErrorJSON("ValidationErrors", e).detail("errors", errs).marshal()
+}
+
+// MarshalJSON implements json.Marshaler, representing w like the errors of
+// the runtime.
+func (w Warning) MarshalJSON() ([]byte, error) {
+	j := (&errorJSON{Kind: "Warning", Message: w.String()}).withLocation(w.locationInfo)
+	if w.value != nil {
+		j.Actual = valueJSON(w.value)
+	}
+	return j.detail("warning", string(w.kind)).marshal()
+}
@@ -0,0 +1,136 @@
+package kaitai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestErrors_MarshalJSON(t *testing.T) {
+	k := NewStream(bytes.NewReader([]byte{1, 2, 3, 4}))
+	if _, err := k.ReadU2le(); err != nil {
+		t.Fatal(err)
+	}
+	_, eos := k.ReadU4be()
+
+	tests := []struct {
+		name string
+		e    error
+		want string
+	}{
+		{
+			"ValidationNotEqualError", NewValidationNotEqualError([]byte{0x89, 'P'}, []byte{0, 0}, k, "/seq/0"),
+			`{"kind":"ValidationNotEqualError","message":"/seq/0: at pos 4: validation failed: not equal, expected 89 50 |.P|, but got 00 00 |..|, first difference at offset 0",` +
+				`"srcPath":"/seq/0","pos":4,"expected":"iVA=","actual":"AAA="}`,
+		},
+		{
+			"ValidationLessThanError", NewValidationLessThanError(3, 1, nil, "/seq/1"),
+			`{"kind":"ValidationLessThanError","message":"/seq/1: at pos N/A: validation failed: not in range, min 3 (0x3), but got 1 (0x1)",` +
+				`"srcPath":"/seq/1","actual":1,"min":3}`,
+		},
+		{
+			"ValidationGreaterThanError", NewValidationGreaterThanError(3, 5, k, "/seq/2"),
+			`{"kind":"ValidationGreaterThanError","message":"/seq/2: at pos 4: validation failed: not in range, max 3 (0x3), but got 5 (0x5)",` +
+				`"srcPath":"/seq/2","pos":4,"actual":5,"max":3}`,
+		},
+		{
+			"ValidationExprError with unmarshalable value", NewValidationExprError(func() {}, nil, "/seq/3"),
+			"", // checked separately, as the message contains a pointer
+		},
+		{
+			"ValidationChecksumError", NewValidationChecksumError("crc32", 0, 4, uint32(1), uint32(2), nil, "/seq/5"),
+			`{"kind":"ValidationChecksumError","message":"/seq/5: at pos N/A: validation failed: crc32 mismatch over bytes [0, 4), computed 2 (0x2), but stored 1 (0x1)",` +
+				`"srcPath":"/seq/5","expected":2,"actual":1,"details":{"algorithm":"crc32","end":4,"start":0}}`,
+		},
+		{
+			"ValidationBytesMismatchError", NewValidationBytesMismatchError([]byte{1, 2}, []byte{1, 3}, nil, "/seq/6"),
+			`{"kind":"ValidationBytesMismatchError","message":"/seq/6: at pos N/A: validation failed: bytes mismatch at offset 1, expected 01 02 |..|, but got 01 03 |..|",` +
+				`"srcPath":"/seq/6","expected":"AQI=","actual":"AQM=","details":{"offset":1}}`,
+		},
+		{
+			"EndOfStreamError", eos,
+			`{"kind":"EndOfStreamError","message":"ReadU4be: unexpected end of stream at pos 2: requested 4 bytes, 2 available","pos":2,` +
+				`"details":{"available":2,"method":"ReadU4be","requested":4},"cause":{"kind":"*errors.errorString","message":"unexpected EOF"}}`,
+		},
+		{
+			"PanicError", PanicError{Value: "boom", Pos: 3, Stack: []byte("goroutine 1 [running]:")},
+			`{"kind":"PanicError","message":"panic during parsing at pos 3: boom","pos":3,"details":{"value":"boom"}}`,
+		},
+		{"EndOfStreamError zero value", EndOfStreamError{}, `{"kind":"EndOfStreamError","message":"unexpected end of stream"}`},
+		{"UndecidedEndiannessError", UndecidedEndiannessError{}, `{"kind":"UndecidedEndiannessError","message":"undecided endianness"}`},
+		{
+			"ConsistencyTerminatorError", NewConsistencyTerminatorError(0, 5, 2, "/seq/4"),
+			`{"kind":"ConsistencyTerminatorError","message":"/seq/4: consistency check failed: terminator 0 (0x0) found inside the data at index 2, expected only at index 5",` +
+				`"srcPath":"/seq/4","expected":5,"actual":2,"details":{"term":0}}`,
+		},
+		{
+			"ParseError", WrapParseErrorIndex(WrapParseError(NewValidationNotInEnumError(7, nil, "/types/entry/seq/0"), "Entry", 8, "kind"), "Root", 0, "entries", 1),
+			`{"kind":"ParseError","message":"Root.entries[1].kind: error parsing Entry at pos 8: /types/entry/seq/0: at pos N/A: validation failed: not in the enum, got 7 (0x7)",` +
+				`"srcPath":"entries[1].kind","pos":8,"details":{"frames":[{"type":"Root","pos":0,"field":"entries","index":1},{"type":"Entry","pos":8,"field":"kind"}]},` +
+				`"cause":{"kind":"ValidationNotInEnumError","message":"/types/entry/seq/0: at pos N/A: validation failed: not in the enum, got 7 (0x7)","srcPath":"/types/entry/seq/0","actual":7}}`,
+		},
+		{
+			"ParseError with a wrapped ParseError",
+			WrapParseError(fmt.Errorf("reading entry: %w", WrapParseError(io.ErrUnexpectedEOF, "Entry", 5, "name")), "Root", 0, "entry"),
+			`{"kind":"ParseError","message":"Root.entry.name: error parsing Entry at pos 5: reading entry: unexpected EOF",` +
+				`"srcPath":"entry.name","pos":5,"details":{"frames":[{"type":"Root","pos":0,"field":"entry"},{"type":"Entry","pos":5,"field":"name"}]},` +
+				`"cause":{"kind":"kaitai.parseContextError","message":"reading entry: unexpected EOF","cause":{"kind":"*errors.errorString","message":"unexpected EOF"}}}`,
+		},
+		{
+			"wrapped by fmt.Errorf", fmt.Errorf("decoding: %w", WriteError{"WriteU1", 3, 1, 0, io.ErrClosedPipe}),
+			`{"kind":"*fmt.wrapError","message":"decoding: WriteU1: error writing 1 bytes at offset 3 (wrote 0): io: read/write on closed pipe",` +
+				`"cause":{"kind":"WriteError","message":"WriteU1: error writing 1 bytes at offset 3 (wrote 0): io: read/write on closed pipe","pos":3,` +
+				`"details":{"method":"WriteU1","requested":1,"written":0},"cause":{"kind":"*errors.errorString","message":"io: read/write on closed pipe"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := causeJSON(tt.e)
+			if tt.want == "" {
+				var v map[string]interface{}
+				if err := json.Unmarshal(got, &v); err != nil {
+					t.Fatalf("causeJSON() = %s, not valid JSON: %v", got, err)
+				}
+				if _, ok := v["actual"].(string); !ok {
+					t.Errorf("causeJSON() = %s, want actual as a string", got)
+				}
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("causeJSON() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPanicError_MarshalJSONWithStack(t *testing.T) {
+	e := PanicError{Value: "boom", Pos: -1, Stack: []byte("goroutine 1 [running]:")}
+	got, err := e.MarshalJSONWithStack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"kind":"PanicError","message":"panic during parsing: boom","details":{"stack":"goroutine 1 [running]:","value":"boom"}}`
+	if string(got) != want {
+		t.Errorf("MarshalJSONWithStack() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestValidationFailedError_MarshalJSON(t *testing.T) {
+	// Every validation error must be usable directly with encoding/json
+	for _, e := range []ValidationFailedError{
+		NewValidationNotEqualError(1, 2, nil, ""),
+		NewValidationLessThanError(1, 2, nil, ""),
+		NewValidationGreaterThanError(1, 2, nil, ""),
+		NewValidationNotAnyOfError(1, nil, ""),
+		NewValidationNotInEnumError(1, nil, ""),
+		NewValidationExprError(1, nil, ""),
+		NewValidationChecksumError("crc32", 0, 1, 1, 2, nil, ""),
+		NewValidationBytesMismatchError([]byte{1}, []byte{2}, nil, ""),
+	} {
+		if _, ok := e.(json.Marshaler); !ok {
+			t.Errorf("%T does not implement json.Marshaler", e)
+		}
+	}
+}
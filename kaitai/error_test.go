@@ -2,7 +2,11 @@ package kaitai
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -13,6 +17,10 @@ func TestEndOfStreamError_Error(t *testing.T) {
 		want string
 	}{
 		{"Test Error", EndOfStreamError{}, "unexpected end of stream"},
+		{
+			"With details", EndOfStreamError{"ReadU4be", 10, 4, 1, io.ErrUnexpectedEOF},
+			"ReadU4be: unexpected end of stream at pos 10: requested 4 bytes, 1 available",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -39,38 +47,249 @@ func TestUndecidedEndiannessError_Error(t *testing.T) {
 		})
 	}
 }
+func TestWriteError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		e    WriteError
+		want string
+	}{
+		{"short write", WriteError{"WriteU4le", 8, 4, 1, nil}, "WriteU4le: short write at offset 8: wrote 1 of 4 bytes"},
+		{
+			"underlying error", WriteError{"WriteBytes", 0, 3, 0, io.ErrClosedPipe},
+			"WriteBytes: error writing 3 bytes at offset 0 (wrote 0): io: read/write on closed pipe",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.Error(); got != tt.want {
+				t.Errorf("WriteError.Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnencodableRuneError_Error(t *testing.T) {
+	e := UnencodableRuneError{2, '\u20ac', nil}
+	want := "cannot encode rune '\u20ac' (U+20AC) at rune index 2"
+	if got := e.Error(); got != want {
+		t.Errorf("UnencodableRuneError.Error() = %q, want %q", got, want)
+	}
+}
+
+func TestTextDecodeError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		e    TextDecodeError
+		want string
+	}{
+		{"without details", TextDecodeError{"hex", 3, nil}, "invalid hex data at offset 3"},
+		{"with details", TextDecodeError{"uuencode", 5, errors.New("line too short")}, "invalid uuencode data at offset 5: line too short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.Error(); got != tt.want {
+				t.Errorf("TextDecodeError.Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// parseEntries mimics generated Read methods, where an array of entries of
+// one u4le name each follows a u1 count.
+func parseEntries(io *Stream) error {
+	readEntry := func() error {
+		pos, _ := io.Pos()
+		_, err := io.ReadU4le()
+		return WrapParseError(err, "Entry", pos, "name")
+	}
+	readHeader := func() error {
+		pos, _ := io.Pos()
+		n, err := io.ReadU1()
+		if err != nil {
+			return WrapParseError(err, "Header", pos, "num_entries")
+		}
+		for i := 0; i < int(n); i++ {
+			if err := readEntry(); err != nil {
+				return WrapParseErrorIndex(err, "Header", pos, "entries", i)
+			}
+		}
+		return nil
+	}
+	return WrapParseError(readHeader(), "Root", 0, "header")
+}
+
+func TestParseError(t *testing.T) {
+	err := parseEntries(NewStream(bytes.NewReader([]byte{5, 1, 2, 3, 4, 5, 6})))
+
+	var pe ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error = %v, want ParseError", err)
+	}
+	if got, want := pe.Path(), "header.entries[1].name"; got != want {
+		t.Errorf("ParseError.Path() = %q, want %q", got, want)
+	}
+	wantFrames := []ParseFrame{
+		{"Root", 0, "header", -1},
+		{"Header", 0, "entries", 1},
+		{"Entry", 5, "name", -1},
+	}
+	if !reflect.DeepEqual(pe.Frames, wantFrames) {
+		t.Errorf("ParseError.Frames = %v, want %v", pe.Frames, wantFrames)
+	}
+	wantMsg := "Root.header.entries[1].name: error parsing Entry at pos 5: ReadU4le: unexpected end of stream at pos 5: requested 4 bytes, 2 available"
+	if got := err.Error(); got != wantMsg {
+		t.Errorf("ParseError.Error() = %q, want %q", got, wantMsg)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("errors.Is(%v, io.ErrUnexpectedEOF) = false, want true", err)
+	}
+
+	if err := parseEntries(NewStream(bytes.NewReader([]byte{1, 1, 2, 3, 4}))); err != nil {
+		t.Errorf("error = %v, want nil", err)
+	}
+}
+
+// opaqueError hides the message of the error it wraps.
+type opaqueError struct{ err error }
+
+func (e opaqueError) Error() string { return "entry failed" }
+
+func (e opaqueError) Unwrap() error { return e.err }
+
+func TestParseError_wrapped(t *testing.T) {
+	tests := []struct {
+		name       string
+		wrap       func(error) error
+		wantPath   string
+		wantFrames []ParseFrame
+		wantMsg    string
+	}{
+		{
+			"fmt.Errorf", func(err error) error { return fmt.Errorf("reading entry: %w", err) },
+			"header.entries[3].name",
+			[]ParseFrame{{"Root", 0, "header", -1}, {"Header", 0, "entries", 3}, {"Entry", 5, "name", -1}},
+			"Root.header.entries[3].name: error parsing Entry at pos 5: reading entry: unexpected EOF",
+		},
+		{
+			"two layers", func(err error) error { return fmt.Errorf("a: %w", fmt.Errorf("b: %w", err)) },
+			"header.entries[3].name",
+			[]ParseFrame{{"Root", 0, "header", -1}, {"Header", 0, "entries", 3}, {"Entry", 5, "name", -1}},
+			"Root.header.entries[3].name: error parsing Entry at pos 5: a: b: unexpected EOF",
+		},
+		{
+			// The message of the nested ParseError can't be separated from
+			// the wrapping error, so it's kept as the cause of a new one
+			"message replaced", func(err error) error { return opaqueError{err} },
+			"header.entries[3]",
+			[]ParseFrame{{"Root", 0, "header", -1}, {"Header", 0, "entries", 3}},
+			"Root.header.entries[3]: error parsing Header at pos 0: entry failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := WrapParseError(io.ErrUnexpectedEOF, "Entry", 5, "name")
+			err := WrapParseErrorIndex(tt.wrap(inner), "Header", 0, "entries", 3)
+			err = WrapParseError(err, "Root", 0, "header")
+
+			var pe ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("error = %v, want ParseError", err)
+			}
+			if got := pe.Path(); got != tt.wantPath {
+				t.Errorf("ParseError.Path() = %q, want %q", got, tt.wantPath)
+			}
+			if !reflect.DeepEqual(pe.Frames, tt.wantFrames) {
+				t.Errorf("ParseError.Frames = %v, want %v", pe.Frames, tt.wantFrames)
+			}
+			if got := err.Error(); got != tt.wantMsg {
+				t.Errorf("ParseError.Error() = %q, want %q", got, tt.wantMsg)
+			}
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("errors.Is(%v, io.ErrUnexpectedEOF) = false, want true", err)
+			}
+		})
+	}
+}
+
 func Test_locationInfo_msgWithLocation(t *testing.T) {
 	type args struct {
 		msg string
 	}
 	tests := []struct {
 		name      string
-		l         locationInfo
+		io        *Stream
+		srcPath   string
 		args      args
 		ioSeekPos int64
 		want      string
 	}{
 		{
-			"msg", locationInfo{NewStream(bytes.NewReader([]byte("test"))), "/seq/0"}, args{"something failed"}, 2,
+			"msg", NewStream(bytes.NewReader([]byte("test"))), "/seq/0", args{"something failed"}, 2,
 			"/seq/0: at pos 2: something failed",
 		},
+		{"without stream", nil, "/seq/1", args{"something failed"}, 0, "/seq/1: at pos N/A: something failed"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := tt.l.io.Seek(tt.ioSeekPos, io.SeekStart)
-			if err != nil {
-				t.Error(err)
-				return
+			if tt.io != nil {
+				if _, err := tt.io.Seek(tt.ioSeekPos, io.SeekStart); err != nil {
+					t.Fatal(err)
+				}
+			}
+			l := newLocationInfo(tt.io, tt.srcPath)
+			if tt.io != nil {
+				// The position must have been captured by newLocationInfo
+				if _, err := tt.io.Seek(0, io.SeekEnd); err != nil {
+					t.Fatal(err)
+				}
 			}
-			if got := tt.l.msgWithLocation(tt.args.msg); got != tt.want {
+			if got := l.msgWithLocation(tt.args.msg); got != tt.want {
 				t.Errorf("locationInfo.msgWithLocation() = %q, want %q", got, tt.want)
 			}
 		})
 	}
 }
 
+func Test_locationInfo_Window(t *testing.T) {
+	data := []byte("0123456789")
+	tests := []struct {
+		name      string
+		r         io.ReadSeeker
+		context   int
+		pos       int64
+		wantStart int64
+		want      []byte
+	}{
+		{"disabled", bytes.NewReader(data), 0, 5, 0, nil},
+		{"middle", bytes.NewReader(data), 2, 5, 3, []byte("3456")},
+		{"start", bytes.NewReader(data), 3, 1, 0, []byte("0123")},
+		{"end", bytes.NewReader(data), 3, 9, 6, []byte("6789")},
+		{"without ReaderAt", struct{ io.ReadSeeker }{bytes.NewReader(data)}, 2, 5, 3, []byte("3456")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := NewStream(tt.r)
+			k.SetErrorContext(tt.context)
+			if _, err := k.Seek(tt.pos, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			l := newLocationInfo(k, "/seq/0")
+			start, got := l.Window()
+			if start != tt.wantStart || !bytes.Equal(got, tt.want) {
+				t.Errorf("locationInfo.Window() = %v, %q, want %v, %q", start, got, tt.wantStart, tt.want)
+			}
+			if pos, _ := k.Pos(); pos != tt.pos {
+				t.Errorf("Stream.Pos() = %v after capturing the window, want %v", pos, tt.pos)
+			}
+		})
+	}
+}
+
 func TestValidationFailedError_interface(t *testing.T) {
 	io := NewStream(bytes.NewReader([]byte("test")))
+	if _, err := io.ReadBytes(2); err != nil {
+		t.Fatal(err)
+	}
 	actual := -1
 	srcPath := "types/header/seq/7"
 	tests := []struct {
@@ -83,6 +302,7 @@ func TestValidationFailedError_interface(t *testing.T) {
 		{"ValidationNotAnyOfError", NewValidationNotAnyOfError(actual, io, srcPath)},
 		{"ValidationNotInEnumError", NewValidationNotInEnumError(actual, io, srcPath)},
 		{"ValidationExprError", NewValidationExprError(actual, io, srcPath)},
+		{"ValidationChecksumError", NewValidationChecksumError("crc32", 0, 2, actual, 5, io, srcPath)},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -99,10 +319,50 @@ func TestValidationFailedError_interface(t *testing.T) {
 			if got := e.SrcPath(); got != srcPath {
 				t.Errorf("%T.SrcPath() = %q, want %q", e, got, srcPath)
 			}
+			if got := e.Pos(); got != 2 {
+				t.Errorf("%T.Pos() = %v, want %v", e, got, 2)
+			}
 		})
 	}
 }
 
+func TestValidationFailedError_Is(t *testing.T) {
+	kinds := []error{
+		ErrNotEqual, ErrLessThan, ErrGreaterThan, ErrNotAnyOf, ErrNotInEnum, ErrExpr, ErrChecksumMismatch, ErrBytesMismatch,
+	}
+	tests := []struct {
+		name string
+		e    error
+		kind error
+	}{
+		{"ValidationNotEqualError", NewValidationNotEqualError(2, 1, nil, ""), ErrNotEqual},
+		{"ValidationLessThanError", NewValidationLessThanError(2, 1, nil, ""), ErrLessThan},
+		{"ValidationGreaterThanError", NewValidationGreaterThanError(2, 3, nil, ""), ErrGreaterThan},
+		{"ValidationNotAnyOfError", NewValidationNotAnyOfError(1, nil, ""), ErrNotAnyOf},
+		{"ValidationNotInEnumError", NewValidationNotInEnumError(1, nil, ""), ErrNotInEnum},
+		{"ValidationExprError", NewValidationExprError(1, nil, ""), ErrExpr},
+		{"ValidationChecksumError", NewValidationChecksumError("crc32", 0, 4, 1, 2, nil, ""), ErrChecksumMismatch},
+		{"ValidationBytesMismatchError", NewValidationBytesMismatchError([]byte{1}, []byte{2}, nil, ""), ErrBytesMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Generated code and callers may wrap the error in several layers
+			err := fmt.Errorf("reading header: %w", WrapParseError(tt.e, "Root", 0, "header"))
+			if !errors.Is(err, ErrValidationFailed) {
+				t.Errorf("errors.Is(%v, ErrValidationFailed) = false, want true", err)
+			}
+			for _, kind := range kinds {
+				if got, want := errors.Is(err, kind), kind == tt.kind; got != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", err, kind, got, want)
+				}
+			}
+		})
+	}
+	if errors.Is(EndOfStreamError{}, ErrValidationFailed) {
+		t.Error("errors.Is(EndOfStreamError{}, ErrValidationFailed) = true, want false")
+	}
+}
+
 func TestValidationNotEqualError_Error(t *testing.T) {
 	io := NewStream(bytes.NewReader([]byte("test")))
 	tests := []struct {
@@ -112,11 +372,11 @@ func TestValidationNotEqualError_Error(t *testing.T) {
 	}{
 		{
 			"integers", NewValidationNotEqualError(42, -1, io, "/seq/0"),
-			"/seq/0: at pos 0: validation failed: not equal, expected 42, but got -1",
+			"/seq/0: at pos 0: validation failed: not equal, expected 42 (0x2a), but got -1 (-0x1)",
 		},
 		{
 			"byte arrays", NewValidationNotEqualError([]uint8{160, 0}, []uint8{0, 160}, io, "/seq/1"),
-			"/seq/1: at pos 0: validation failed: not equal, expected [160 0], but got [0 160]",
+			"/seq/1: at pos 0: validation failed: not equal, expected a0 00 |..|, but got 00 a0 |..|, first difference at offset 0",
 		},
 		{
 			"strings", NewValidationNotEqualError("ba", "ab", io, "/seq/2"),
@@ -159,11 +419,11 @@ func TestValidationLessThanError_Error(t *testing.T) {
 	}{
 		{
 			"integers", NewValidationLessThanError(42, -42, io, "/seq/0"),
-			"/seq/0: at pos 0: validation failed: not in range, min 42, but got -42",
+			"/seq/0: at pos 0: validation failed: not in range, min 42 (0x2a), but got -42 (-0x2a)",
 		},
 		{
 			"byte arrays", NewValidationLessThanError([]uint8{160, 0}, []uint8{0, 160}, io, "/seq/1"),
-			"/seq/1: at pos 0: validation failed: not in range, min [160 0], but got [0 160]",
+			"/seq/1: at pos 0: validation failed: not in range, min a0 00 |..|, but got 00 a0 |..|",
 		},
 		{
 			"strings", NewValidationLessThanError("ba", "ab", io, "/seq/2"),
@@ -205,11 +465,11 @@ func TestValidationGreaterThanError_Error(t *testing.T) {
 	}{
 		{
 			"integers", NewValidationGreaterThanError(-42, 42, io, "/seq/0"),
-			"/seq/0: at pos 0: validation failed: not in range, max -42, but got 42",
+			"/seq/0: at pos 0: validation failed: not in range, max -42 (-0x2a), but got 42 (0x2a)",
 		},
 		{
 			"byte arrays", NewValidationGreaterThanError([]uint8{0, 160}, []uint8{160, 0}, io, "/seq/1"),
-			"/seq/1: at pos 0: validation failed: not in range, max [0 160], but got [160 0]",
+			"/seq/1: at pos 0: validation failed: not in range, max 00 a0 |..|, but got a0 00 |..|",
 		},
 		{
 			"strings", NewValidationGreaterThanError("ab", "ba", io, "/seq/2"),
@@ -251,11 +511,11 @@ func TestValidationNotAnyOfError_Error(t *testing.T) {
 	}{
 		{
 			"integer", NewValidationNotAnyOfError(-42, io, "/seq/0"),
-			"/seq/0: at pos 0: validation failed: not any of the list, got -42",
+			"/seq/0: at pos 0: validation failed: not any of the list, got -42 (-0x2a)",
 		},
 		{
 			"byte array", NewValidationNotAnyOfError([]uint8{0, 160}, io, "/seq/1"),
-			"/seq/1: at pos 0: validation failed: not any of the list, got [0 160]",
+			"/seq/1: at pos 0: validation failed: not any of the list, got 00 a0 |..|",
 		},
 		{
 			"string", NewValidationNotAnyOfError("ab", io, "/seq/2"),
@@ -280,7 +540,7 @@ func TestValidationNotInEnumError_Error(t *testing.T) {
 	}{
 		{
 			"integer", NewValidationNotInEnumError(-42, io, "/seq/0"),
-			"/seq/0: at pos 0: validation failed: not in the enum, got -42",
+			"/seq/0: at pos 0: validation failed: not in the enum, got -42 (-0x2a)",
 		},
 	}
 	for _, tt := range tests {
@@ -301,11 +561,11 @@ func TestValidationExprError_Error(t *testing.T) {
 	}{
 		{
 			"integer", NewValidationExprError(-42, io, "/seq/0"),
-			"/seq/0: at pos 0: validation failed: not matching the expression, got -42",
+			"/seq/0: at pos 0: validation failed: not matching the expression, got -42 (-0x2a)",
 		},
 		{
 			"byte array", NewValidationExprError([]uint8{0, 160}, io, "/seq/1"),
-			"/seq/1: at pos 0: validation failed: not matching the expression, got [0 160]",
+			"/seq/1: at pos 0: validation failed: not matching the expression, got 00 a0 |..|",
 		},
 		{
 			"string", NewValidationExprError("ab", io, "/seq/2"),
@@ -320,3 +580,168 @@ func TestValidationExprError_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestValidationChecksumError_Error(t *testing.T) {
+	io := NewStream(bytes.NewReader([]byte("test")))
+	tests := []struct {
+		name string
+		e    ValidationChecksumError
+		want string
+	}{
+		{
+			"integer", NewValidationChecksumError("crc32", 16, 48, uint32(0xcbf43926), uint32(0x414fa339), io, "/seq/0"),
+			"/seq/0: at pos 0: validation failed: crc32 mismatch over bytes [16, 48), computed 1095738169 (0x414fa339), but stored 3421780262 (0xcbf43926)",
+		},
+		{
+			"byte array", NewValidationChecksumError("md5", 0, 4, []byte{0xd4, 0x1d}, []byte{0x09, 0x8f}, io, "/seq/1"),
+			"/seq/1: at pos 0: validation failed: md5 mismatch over bytes [0, 4), computed 09 8f |..|, but stored d4 1d |..|",
+		},
+		{
+			"empty range", NewValidationChecksumError("crc32", 5, 5, uint32(1), uint32(0), io, "/seq/2"),
+			"/seq/2: at pos 0: validation failed: crc32 mismatch over bytes [5, 5), computed 0 (0x0), but stored 1 (0x1)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.Error(); got != tt.want {
+				t.Errorf("ValidationChecksumError.Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationChecksumError_getters(t *testing.T) {
+	e := NewValidationChecksumError("adler32", 4, 12, 1, 2, nil, "")
+	if got := e.Algorithm(); got != "adler32" {
+		t.Errorf("Algorithm() = %q, want %q", got, "adler32")
+	}
+	if start, end := e.Range(); start != 4 || end != 12 {
+		t.Errorf("Range() = %v, %v, want 4, 12", start, end)
+	}
+	if got := e.Stored(); got != 1 {
+		t.Errorf("Stored() = %v, want 1", got)
+	}
+	if got := e.Computed(); got != 2 {
+		t.Errorf("Computed() = %v, want 2", got)
+	}
+}
+
+func TestValidationBytesMismatchError_Error(t *testing.T) {
+	io := NewStream(bytes.NewReader([]byte("test")))
+	long := bytes.Repeat([]byte{'a'}, 64)
+	longActual := append(bytes.Repeat([]byte{'a'}, 40), bytes.Repeat([]byte{'b'}, 24)...)
+	tests := []struct {
+		name string
+		e    ValidationBytesMismatchError
+		want string
+	}{
+		{
+			"short", NewValidationBytesMismatchError([]byte("PK\x03\x04"), []byte("PK\x05\x06"), io, "/seq/0"),
+			"/seq/0: at pos 0: validation failed: bytes mismatch at offset 2, expected 50 4b 03 04 |PK..|, but got 50 4b 05 06 |PK..|",
+		},
+		{
+			"prefix", NewValidationBytesMismatchError([]byte("GIF89a"), []byte("GIF"), io, "/seq/1"),
+			"/seq/1: at pos 0: validation failed: bytes mismatch at offset 3, expected 47 49 46 38 39 61 |GIF89a|, but got 47 49 46 |GIF|",
+		},
+		{
+			"long", NewValidationBytesMismatchError(long, longActual, io, "/seq/2"),
+			"/seq/2: at pos 0: validation failed: bytes mismatch at offset 40, expected ... " +
+				strings.TrimSpace(strings.Repeat("61 ", 32)) + " |" + strings.Repeat("a", 32) + "| (64 bytes, showing offsets 32-63), but got ... " +
+				strings.TrimSpace(strings.Repeat("61 ", 8)+strings.Repeat("62 ", 24)) + " |" + strings.Repeat("a", 8) + strings.Repeat("b", 24) +
+				"| (64 bytes, showing offsets 32-63)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.Error(); got != tt.want {
+				t.Errorf("ValidationBytesMismatchError.Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationBytesMismatchError_Window(t *testing.T) {
+	expected := bytes.Repeat([]byte{0}, 100)
+	actual := bytes.Clone(expected)
+	actual[50] = 1
+	e := NewValidationBytesMismatchError(expected, actual, nil, "")
+	if got := e.Offset(); got != 50 {
+		t.Errorf("Offset() = %v, want 50", got)
+	}
+	if got := e.Actual(); !bytes.Equal(got.([]byte), actual) {
+		t.Errorf("Actual() = %v, want %v", got, actual)
+	}
+	if got := e.Expected(); !bytes.Equal(got, expected) {
+		t.Errorf("Expected() = %v, want %v", got, expected)
+	}
+	if off, w := e.ExpectedWindow(); off != 42 || !bytes.Equal(w, expected[42:74]) {
+		t.Errorf("ExpectedWindow() = %v, %v, want 42, %v", off, w, expected[42:74])
+	}
+	if off, w := e.ActualWindow(); off != 42 || !bytes.Equal(w, actual[42:74]) {
+		t.Errorf("ActualWindow() = %v, %v, want 42, %v", off, w, actual[42:74])
+	}
+}
+
+func TestConsistencyFailedError_interface(t *testing.T) {
+	expected := 4
+	actual := 3
+	srcPath := "types/header/seq/2"
+	tests := []struct {
+		name string
+		e    interface{}
+	}{
+		{"ConsistencyError", NewConsistencyError(expected, actual, srcPath)},
+		{"ConsistencyCountMismatchError", NewConsistencyCountMismatchError(expected, actual, srcPath)},
+		{"ConsistencySizeMismatchError", NewConsistencySizeMismatchError(expected, actual, srcPath)},
+		{"ConsistencyTerminatorError", NewConsistencyTerminatorError(0, expected, actual, srcPath)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := tt.e.(ConsistencyFailedError)
+			if ok != true {
+				t.Fatalf("Type %T does not implement ConsistencyFailedError", tt.e)
+			}
+			if got := e.Expected(); got != expected {
+				t.Errorf("%T.Expected() = %v, want %v", e, got, expected)
+			}
+			if got := e.Actual(); got != actual {
+				t.Errorf("%T.Actual() = %v, want %v", e, got, actual)
+			}
+			if got := e.SrcPath(); got != srcPath {
+				t.Errorf("%T.SrcPath() = %q, want %q", e, got, srcPath)
+			}
+		})
+	}
+}
+
+func TestConsistencyError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		e    error
+		want string
+	}{
+		{
+			"generic", NewConsistencyError("ab", "ba", "/seq/0"),
+			"/seq/0: consistency check failed: expected ab, but got ba",
+		},
+		{
+			"count mismatch", NewConsistencyCountMismatchError(3, 2, "/seq/1"),
+			"/seq/1: consistency check failed: count mismatch, expected 3 elements, but got 2",
+		},
+		{
+			"size mismatch", NewConsistencySizeMismatchError(16, 12, "/seq/2"),
+			"/seq/2: consistency check failed: size mismatch, expected 16 bytes, but got 12",
+		},
+		{
+			"terminator", NewConsistencyTerminatorError(0, 5, 2, "/seq/3"),
+			"/seq/3: consistency check failed: terminator 0 (0x0) found inside the data at index 2, expected only at index 5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.Error(); got != tt.want {
+				t.Errorf("%T.Error() = %q, want %q", tt.e, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package kaitai
+
+import (
+	"compress/lzw"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const lzwMaxWidth = 12
+
+// Errors returned for invalid LZW parameters and data.
+var (
+	ErrLzwLitWidth    = errors.New("LZW literal width out of range")
+	ErrInvalidLzwCode = errors.New("invalid LZW code")
+)
+
+// decodeLzwEarlyChange decompresses LZW data in which the code width grows
+// one code earlier than in GIF, as done by TIFF and PDF. The standard
+// library's compress/lzw doesn't support this variant.
+func decodeLzwEarlyChange(in []byte, order lzw.Order, litWidth int, maxSize int64) ([]byte, error) {
+	return decodeLzw(in, order, litWidth, 1, maxSize)
+}
+
+// decodeLzw decompresses LZW data, increasing the code width once the next
+// table entry plus earlyChange no longer fits.
+func decodeLzw(in []byte, order lzw.Order, litWidth int, earlyChange int, maxSize int64) ([]byte, error) {
+	if litWidth < 2 || litWidth > 8 {
+		return nil, fmt.Errorf("lzw: litWidth %d: %w", litWidth, ErrLzwLitWidth)
+	}
+	const tableSize = 1 << lzwMaxWidth
+	var (
+		prefix [tableSize]uint16
+		suffix [tableSize]byte
+		first  [tableSize]byte
+		length [tableSize]int
+	)
+	clearCode := 1 << litWidth
+	eoiCode := clearCode + 1
+	for c := 0; c < clearCode; c++ {
+		suffix[c] = byte(c)
+		first[c] = byte(c)
+		length[c] = 1
+	}
+
+	var (
+		out   []byte
+		acc   uint32
+		nBits uint
+		pos   int
+		width = uint(litWidth + 1)
+		next  = eoiCode + 1
+		prev  = -1
+	)
+	for {
+		for nBits < width {
+			if pos == len(in) {
+				return out, io.ErrUnexpectedEOF
+			}
+			if order == lzw.LSB {
+				acc |= uint32(in[pos]) << nBits
+			} else {
+				acc = acc<<8 | uint32(in[pos])
+			}
+			pos++
+			nBits += 8
+		}
+		var code int
+		if order == lzw.LSB {
+			code = int(acc & (1<<width - 1))
+			acc >>= width
+		} else {
+			code = int(acc >> (nBits - width) & (1<<width - 1))
+		}
+		nBits -= width
+
+		switch {
+		case code == clearCode:
+			width = uint(litWidth + 1)
+			next = eoiCode + 1
+			prev = -1
+			continue
+		case code == eoiCode:
+			return out, nil
+		}
+
+		canAdd := prev != -1 && next < tableSize-earlyChange
+		if code > next || code == next && !canAdd {
+			return out, fmt.Errorf("lzw: code %d at byte %d: %w", code, pos, ErrInvalidLzwCode)
+		}
+		if canAdd {
+			fc := first[prev]
+			if code != next {
+				fc = first[code]
+			}
+			prefix[next] = uint16(prev)
+			suffix[next] = fc
+			first[next] = first[prev]
+			length[next] = length[prev] + 1
+			next++
+		}
+
+		n := length[code]
+		if maxSize > 0 && int64(len(out)+n) > maxSize {
+			return out, ErrMaxSizeExceeded
+		}
+		out = append(out, make([]byte, n)...)
+		for i, c := len(out)-1, code; i >= len(out)-n; i-- {
+			out[i] = suffix[c]
+			c = int(prefix[c])
+		}
+		prev = code
+
+		if next+earlyChange >= 1<<width && width < lzwMaxWidth {
+			width++
+		}
+	}
+}
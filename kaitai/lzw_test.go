@@ -0,0 +1,145 @@
+package kaitai
+
+import (
+	"bytes"
+	"compress/lzw"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// encodeLzwTIFF compresses data like libtiff's LZW encoder: MSB order,
+// 8-bit literals, early change and a clear code once the table is full.
+func encodeLzwTIFF(data []byte) []byte {
+	var (
+		out   []byte
+		acc   uint64
+		nBits uint
+		width uint = 9
+	)
+	put := func(code int) {
+		acc = acc<<width | uint64(code)
+		nBits += width
+		for nBits >= 8 {
+			out = append(out, byte(acc>>(nBits-8)))
+			nBits -= 8
+		}
+	}
+	var dict map[string]int
+	var freeEnt int
+	reset := func() {
+		dict = make(map[string]int)
+		for i := 0; i < 256; i++ {
+			dict[string([]byte{byte(i)})] = i
+		}
+		freeEnt = 258
+	}
+	bump := func() {
+		freeEnt++
+		if freeEnt == 4094 {
+			put(256)
+			reset()
+			width = 9
+		} else if freeEnt > 1<<width-1 {
+			width++
+		}
+	}
+
+	reset()
+	put(256)
+	w := ""
+	for _, c := range data {
+		wc := w + string([]byte{c})
+		if _, ok := dict[wc]; ok {
+			w = wc
+			continue
+		}
+		put(dict[w])
+		dict[wc] = freeEnt
+		bump()
+		w = string([]byte{c})
+	}
+	if w != "" {
+		put(dict[w])
+		bump()
+	}
+	put(257)
+	if nBits > 0 {
+		out = append(out, byte(acc<<(8-nBits)))
+	}
+	return out
+}
+
+// lzwTestData returns compressible pseudo-random data.
+func lzwTestData(n int, alphabet int) []byte {
+	rnd := rand.New(rand.NewSource(1))
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(rnd.Intn(alphabet))
+	}
+	return data
+}
+
+func Test_decodeLzw(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    lzw.Order
+		litWidth int
+		data     []byte
+	}{
+		{"GIF", lzw.LSB, 8, lzwTestData(50000, 16)},
+		{"GIF small code size", lzw.LSB, 2, lzwTestData(10000, 4)},
+		{"MSB", lzw.MSB, 8, lzwTestData(50000, 200)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			w := lzw.NewWriter(&b, tt.order, tt.litWidth)
+			if _, err := w.Write(tt.data); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := decodeLzw(b.Bytes(), tt.order, tt.litWidth, 0, 0)
+			if err != nil {
+				t.Fatalf("decodeLzw() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("decodeLzw() differs from compress/lzw input")
+			}
+		})
+	}
+}
+
+func Test_decodeLzw_invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       []byte
+		litWidth int
+		wantErr  error
+	}{
+		// The 9-bit code 300 refers past the next table entry, 258
+		{"code out of table", []byte{0x2c, 0x01}, 8, ErrInvalidLzwCode},
+		{"litWidth too small", []byte{0}, 1, ErrLzwLitWidth},
+		{"litWidth too large", []byte{0}, 9, ErrLzwLitWidth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeLzw(tt.in, lzw.LSB, tt.litWidth, 0, 0); !errors.Is(err, tt.wantErr) {
+				t.Errorf("decodeLzw() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_decodeLzwEarlyChange(t *testing.T) {
+	data := lzwTestData(100000, 64)
+	got, err := decodeLzwEarlyChange(encodeLzwTIFF(data), lzw.MSB, 8, 0)
+	if err != nil {
+		t.Fatalf("decodeLzwEarlyChange() error = %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("decodeLzwEarlyChange() differs from the encoded data")
+	}
+}
@@ -0,0 +1,357 @@
+package process
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rc4"
+	"errors"
+	"fmt"
+)
+
+// ErrIVSize is returned when the initialization vector passed to a cipher
+// doesn't have the length of the cipher's block.
+var ErrIVSize = errors.New("invalid IV size")
+
+// Padding selects how the plaintext of a block cipher mode is padded to a
+// whole number of blocks.
+type Padding int
+
+const (
+	// PaddingNone requires the plaintext to be a whole number of blocks.
+	PaddingNone Padding = iota
+	// PaddingPKCS7 appends n bytes of value n, as specified in RFC 5652.
+	// A full block of padding is added if the plaintext is already a whole
+	// number of blocks.
+	PaddingPKCS7
+	// PaddingZero appends zero bytes up to the next block boundary.
+	// Removing it also strips any zero bytes ending the plaintext itself,
+	// so it's only suitable for data which can't end with a zero byte.
+	PaddingZero
+)
+
+func (p Padding) String() string {
+	switch p {
+	case PaddingNone:
+		return "no"
+	case PaddingPKCS7:
+		return "PKCS#7"
+	case PaddingZero:
+		return "zero"
+	}
+	return fmt.Sprintf("Padding(%d)", int(p))
+}
+
+// PaddingError is returned when data can't be split into blocks or padded
+// data doesn't end with valid padding.
+type PaddingError struct {
+	Padding   Padding
+	BlockSize int
+	// Length is the length of the data being encrypted or decrypted.
+	Length int
+	Reason string
+}
+
+func (e PaddingError) Error() string {
+	return fmt.Sprintf("invalid data for %s padding with %d-byte blocks: %s", e.Padding, e.BlockSize, e.Reason)
+}
+
+// DecryptAESECB decrypts data with AES in ECB mode and removes the padding.
+// The length of key selects AES-128, AES-192 or AES-256.
+func DecryptAESECB(data, key []byte, padding Padding) ([]byte, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("DecryptAESECB: %w", err)
+	}
+	out, err := decryptBlocks(b, nil, data, padding)
+	if err != nil {
+		return nil, fmt.Errorf("DecryptAESECB: %w", err)
+	}
+	return out, nil
+}
+
+// EncryptAESECB pads data and encrypts it with AES in ECB mode, the inverse
+// of DecryptAESECB.
+func EncryptAESECB(data, key []byte, padding Padding) ([]byte, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("EncryptAESECB: %w", err)
+	}
+	out, err := encryptBlocks(b, nil, data, padding)
+	if err != nil {
+		return nil, fmt.Errorf("EncryptAESECB: %w", err)
+	}
+	return out, nil
+}
+
+// DecryptAESCBC decrypts data with AES in CBC mode, starting with the
+// 16-byte iv, and removes the padding.
+func DecryptAESCBC(data, key, iv []byte, padding Padding) ([]byte, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("DecryptAESCBC: %w", err)
+	}
+	if err := checkIV(b, iv); err != nil {
+		return nil, fmt.Errorf("DecryptAESCBC: %w", err)
+	}
+	out, err := decryptBlocks(b, iv, data, padding)
+	if err != nil {
+		return nil, fmt.Errorf("DecryptAESCBC: %w", err)
+	}
+	return out, nil
+}
+
+// EncryptAESCBC pads data and encrypts it with AES in CBC mode, the inverse
+// of DecryptAESCBC.
+func EncryptAESCBC(data, key, iv []byte, padding Padding) ([]byte, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("EncryptAESCBC: %w", err)
+	}
+	if err := checkIV(b, iv); err != nil {
+		return nil, fmt.Errorf("EncryptAESCBC: %w", err)
+	}
+	out, err := encryptBlocks(b, iv, data, padding)
+	if err != nil {
+		return nil, fmt.Errorf("EncryptAESCBC: %w", err)
+	}
+	return out, nil
+}
+
+// DecryptAESCTR decrypts data with AES in CTR mode, using the 16-byte iv as
+// the initial counter block. CTR mode needs no padding.
+func DecryptAESCTR(data, key, iv []byte) ([]byte, error) {
+	out, err := aesCTR(data, key, iv)
+	if err != nil {
+		return nil, fmt.Errorf("DecryptAESCTR: %w", err)
+	}
+	return out, nil
+}
+
+// EncryptAESCTR encrypts data with AES in CTR mode, the inverse of
+// DecryptAESCTR. As CTR mode xors the data with a key stream, this is the
+// same operation as decryption.
+func EncryptAESCTR(data, key, iv []byte) ([]byte, error) {
+	out, err := aesCTR(data, key, iv)
+	if err != nil {
+		return nil, fmt.Errorf("EncryptAESCTR: %w", err)
+	}
+	return out, nil
+}
+
+func aesCTR(data, key, iv []byte) ([]byte, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIV(b, iv); err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data))
+	cipher.NewCTR(b, iv).XORKeyStream(out, data)
+	return out, nil
+}
+
+// DecryptDESECB decrypts data with DES in ECB mode and removes the padding.
+// An 8-byte key selects DES, while 16- and 24-byte keys select triple DES
+// with two or three keys.
+func DecryptDESECB(data, key []byte, padding Padding) ([]byte, error) {
+	b, err := newDESCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("DecryptDESECB: %w", err)
+	}
+	out, err := decryptBlocks(b, nil, data, padding)
+	if err != nil {
+		return nil, fmt.Errorf("DecryptDESECB: %w", err)
+	}
+	return out, nil
+}
+
+// EncryptDESECB pads data and encrypts it with DES in ECB mode, the inverse
+// of DecryptDESECB.
+func EncryptDESECB(data, key []byte, padding Padding) ([]byte, error) {
+	b, err := newDESCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("EncryptDESECB: %w", err)
+	}
+	out, err := encryptBlocks(b, nil, data, padding)
+	if err != nil {
+		return nil, fmt.Errorf("EncryptDESECB: %w", err)
+	}
+	return out, nil
+}
+
+// DecryptDESCBC decrypts data with DES in CBC mode, starting with the 8-byte
+// iv, and removes the padding. The key is interpreted as in DecryptDESECB.
+func DecryptDESCBC(data, key, iv []byte, padding Padding) ([]byte, error) {
+	b, err := newDESCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("DecryptDESCBC: %w", err)
+	}
+	if err := checkIV(b, iv); err != nil {
+		return nil, fmt.Errorf("DecryptDESCBC: %w", err)
+	}
+	out, err := decryptBlocks(b, iv, data, padding)
+	if err != nil {
+		return nil, fmt.Errorf("DecryptDESCBC: %w", err)
+	}
+	return out, nil
+}
+
+// EncryptDESCBC pads data and encrypts it with DES in CBC mode, the inverse
+// of DecryptDESCBC.
+func EncryptDESCBC(data, key, iv []byte, padding Padding) ([]byte, error) {
+	b, err := newDESCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("EncryptDESCBC: %w", err)
+	}
+	if err := checkIV(b, iv); err != nil {
+		return nil, fmt.Errorf("EncryptDESCBC: %w", err)
+	}
+	out, err := encryptBlocks(b, iv, data, padding)
+	if err != nil {
+		return nil, fmt.Errorf("EncryptDESCBC: %w", err)
+	}
+	return out, nil
+}
+
+// newDESCipher creates a DES or triple DES cipher depending on the length of
+// key. A 16-byte key is expanded to the three keys K1, K2, K1.
+func newDESCipher(key []byte) (cipher.Block, error) {
+	switch len(key) {
+	case 8:
+		return des.NewCipher(key)
+	case 16:
+		return des.NewTripleDESCipher(append(bytes.Clone(key), key[:8]...))
+	}
+	return des.NewTripleDESCipher(key)
+}
+
+// DecryptRC4 decrypts data with the RC4 stream cipher.
+func DecryptRC4(data, key []byte) ([]byte, error) {
+	out, err := rc4XOR(data, key)
+	if err != nil {
+		return nil, fmt.Errorf("DecryptRC4: %w", err)
+	}
+	return out, nil
+}
+
+// EncryptRC4 encrypts data with the RC4 stream cipher, the inverse of
+// DecryptRC4. As RC4 xors the data with a key stream, this is the same
+// operation as decryption.
+func EncryptRC4(data, key []byte) ([]byte, error) {
+	out, err := rc4XOR(data, key)
+	if err != nil {
+		return nil, fmt.Errorf("EncryptRC4: %w", err)
+	}
+	return out, nil
+}
+
+func rc4XOR(data, key []byte) ([]byte, error) {
+	c, err := rc4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out, nil
+}
+
+func checkIV(b cipher.Block, iv []byte) error {
+	if len(iv) != b.BlockSize() {
+		return fmt.Errorf("IV length %d, want %d: %w", len(iv), b.BlockSize(), ErrIVSize)
+	}
+	return nil
+}
+
+// decryptBlocks decrypts data in CBC mode if iv is not nil, in ECB mode
+// otherwise, and removes the padding.
+func decryptBlocks(b cipher.Block, iv, data []byte, padding Padding) ([]byte, error) {
+	bs := b.BlockSize()
+	if len(data)%bs != 0 {
+		return nil, PaddingError{padding, bs, len(data), "length is not a multiple of the block size"}
+	}
+	out := make([]byte, len(data))
+	if iv != nil {
+		cipher.NewCBCDecrypter(b, iv).CryptBlocks(out, data)
+	} else {
+		for i := 0; i < len(data); i += bs {
+			b.Decrypt(out[i:i+bs], data[i:i+bs])
+		}
+	}
+	return unpad(out, bs, padding)
+}
+
+// encryptBlocks pads data and encrypts it in CBC mode if iv is not nil, in
+// ECB mode otherwise.
+func encryptBlocks(b cipher.Block, iv, data []byte, padding Padding) ([]byte, error) {
+	bs := b.BlockSize()
+	out, err := pad(data, bs, padding)
+	if err != nil {
+		return nil, err
+	}
+	if iv != nil {
+		cipher.NewCBCEncrypter(b, iv).CryptBlocks(out, out)
+	} else {
+		for i := 0; i < len(out); i += bs {
+			b.Encrypt(out[i:i+bs], out[i:i+bs])
+		}
+	}
+	return out, nil
+}
+
+// pad returns a copy of data padded to a multiple of blockSize.
+func pad(data []byte, blockSize int, padding Padding) ([]byte, error) {
+	var n int
+	switch padding {
+	case PaddingNone:
+		if len(data)%blockSize != 0 {
+			return nil, PaddingError{padding, blockSize, len(data), "length is not a multiple of the block size"}
+		}
+	case PaddingPKCS7:
+		n = blockSize - len(data)%blockSize
+	case PaddingZero:
+		n = (blockSize - len(data)%blockSize) % blockSize
+	default:
+		return nil, PaddingError{padding, blockSize, len(data), "unknown padding"}
+	}
+	out := make([]byte, len(data)+n)
+	copy(out, data)
+	if padding == PaddingPKCS7 {
+		for i := len(data); i < len(out); i++ {
+			out[i] = byte(n)
+		}
+	}
+	return out, nil
+}
+
+// unpad removes the padding from data, whose length is a multiple of
+// blockSize.
+func unpad(data []byte, blockSize int, padding Padding) ([]byte, error) {
+	switch padding {
+	case PaddingNone:
+		return data, nil
+	case PaddingPKCS7:
+		if len(data) == 0 {
+			return nil, PaddingError{padding, blockSize, len(data), "no padding in empty data"}
+		}
+		n := int(data[len(data)-1])
+		if n == 0 || n > blockSize {
+			return nil, PaddingError{padding, blockSize, len(data), fmt.Sprintf("invalid padding length %d", n)}
+		}
+		for _, b := range data[len(data)-n:] {
+			if int(b) != n {
+				return nil, PaddingError{padding, blockSize, len(data), "padding bytes don't match the padding length"}
+			}
+		}
+		return data[:len(data)-n], nil
+	case PaddingZero:
+		end := len(data)
+		for end > 0 && end > len(data)-blockSize+1 && data[end-1] == 0 {
+			end--
+		}
+		return data[:end], nil
+	}
+	return nil, PaddingError{padding, blockSize, len(data), "unknown padding"}
+}
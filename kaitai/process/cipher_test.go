@@ -0,0 +1,219 @@
+package process
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/des"
+	"crypto/rc4"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestCiphers checks known vectors from FIPS-197, NIST SP 800-38A and the
+// DES and RC4 literature, as well as the round trip through the encryption
+// functions.
+func TestCiphers(t *testing.T) {
+	type cryptFunc func(data []byte) ([]byte, error)
+	nistKey := unhex(t, "2b7e151628aed2a6abf7158809cf4f3c")
+	nistPlain := unhex(t, "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51")
+	cbcIV := unhex(t, "000102030405060708090a0b0c0d0e0f")
+	ctrIV := unhex(t, "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	desKey := unhex(t, "133457799bbcdff1")
+
+	tests := []struct {
+		name    string
+		decrypt cryptFunc
+		encrypt cryptFunc
+		cipher  []byte
+		plain   []byte
+	}{
+		{
+			"AES-128-ECB",
+			func(d []byte) ([]byte, error) { return DecryptAESECB(d, cbcIV, PaddingNone) },
+			func(d []byte) ([]byte, error) { return EncryptAESECB(d, cbcIV, PaddingNone) },
+			unhex(t, "69c4e0d86a7b0430d8cdb78070b4c55a"), unhex(t, "00112233445566778899aabbccddeeff"),
+		},
+		{
+			"AES-128-CBC",
+			func(d []byte) ([]byte, error) { return DecryptAESCBC(d, nistKey, cbcIV, PaddingNone) },
+			func(d []byte) ([]byte, error) { return EncryptAESCBC(d, nistKey, cbcIV, PaddingNone) },
+			unhex(t, "7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b2"), nistPlain,
+		},
+		{
+			"AES-128-CTR",
+			func(d []byte) ([]byte, error) { return DecryptAESCTR(d, nistKey, ctrIV) },
+			func(d []byte) ([]byte, error) { return EncryptAESCTR(d, nistKey, ctrIV) },
+			unhex(t, "874d6191b620e3261bef6864990db6ce9806f66b7970fdff8617187bb9fffdff"), nistPlain,
+		},
+		{
+			"AES-128-CTR partial block",
+			func(d []byte) ([]byte, error) { return DecryptAESCTR(d, nistKey, ctrIV) },
+			func(d []byte) ([]byte, error) { return EncryptAESCTR(d, nistKey, ctrIV) },
+			unhex(t, "874d6191b620e3261bef6864990db6ce98"), nistPlain[:17],
+		},
+		{
+			"DES-ECB",
+			func(d []byte) ([]byte, error) { return DecryptDESECB(d, desKey, PaddingNone) },
+			func(d []byte) ([]byte, error) { return EncryptDESECB(d, desKey, PaddingNone) },
+			unhex(t, "85e813540f0ab405"), unhex(t, "0123456789abcdef"),
+		},
+		{
+			"3DES-ECB with equal keys",
+			func(d []byte) ([]byte, error) { return DecryptDESECB(d, bytes.Repeat(desKey, 3), PaddingNone) },
+			func(d []byte) ([]byte, error) { return EncryptDESECB(d, bytes.Repeat(desKey, 3), PaddingNone) },
+			unhex(t, "85e813540f0ab405"), unhex(t, "0123456789abcdef"),
+		},
+		{
+			"2-key 3DES-ECB with equal keys",
+			func(d []byte) ([]byte, error) { return DecryptDESECB(d, bytes.Repeat(desKey, 2), PaddingNone) },
+			func(d []byte) ([]byte, error) { return EncryptDESECB(d, bytes.Repeat(desKey, 2), PaddingNone) },
+			unhex(t, "85e813540f0ab405"), unhex(t, "0123456789abcdef"),
+		},
+		{
+			"RC4",
+			func(d []byte) ([]byte, error) { return DecryptRC4(d, []byte("Key")) },
+			func(d []byte) ([]byte, error) { return EncryptRC4(d, []byte("Key")) },
+			unhex(t, "bbf316e8d940af0ad3"), []byte("Plaintext"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.decrypt(tt.cipher)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.plain) {
+				t.Errorf("decrypt = %x, want %x", got, tt.plain)
+			}
+			got, err = tt.encrypt(tt.plain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.cipher) {
+				t.Errorf("encrypt = %x, want %x", got, tt.cipher)
+			}
+		})
+	}
+}
+
+func TestPadding_roundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 32)
+	iv := bytes.Repeat([]byte{0x24}, 16)
+	desIV := iv[:8]
+	tests := []struct {
+		name      string
+		data      []byte
+		padding   Padding
+		wantLen   int
+		blockSize int
+	}{
+		{"PKCS#7 partial block", []byte("hello"), PaddingPKCS7, 16, 16},
+		{"PKCS#7 full block", bytes.Repeat([]byte{1}, 16), PaddingPKCS7, 32, 16},
+		{"PKCS#7 empty", []byte{}, PaddingPKCS7, 16, 16},
+		{"zero partial block", []byte("hello"), PaddingZero, 16, 16},
+		{"zero full block", bytes.Repeat([]byte{1}, 16), PaddingZero, 16, 16},
+		{"DES PKCS#7", []byte("hello"), PaddingPKCS7, 8, 8},
+		{"DES zero", []byte("hello world"), PaddingZero, 16, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var enc []byte
+			var err error
+			if tt.blockSize == des.BlockSize {
+				enc, err = EncryptDESCBC(tt.data, key[:24], desIV, tt.padding)
+			} else {
+				enc, err = EncryptAESCBC(tt.data, key, iv, tt.padding)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(enc) != tt.wantLen {
+				t.Errorf("encrypted length = %v, want %v", len(enc), tt.wantLen)
+			}
+			var dec []byte
+			if tt.blockSize == des.BlockSize {
+				dec, err = DecryptDESCBC(enc, key[:24], desIV, tt.padding)
+			} else {
+				dec, err = DecryptAESCBC(enc, key, iv, tt.padding)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(dec, tt.data) {
+				t.Errorf("decrypted = %x, want %x", dec, tt.data)
+			}
+		})
+	}
+}
+
+func TestCiphers_errors(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 16)
+	iv := bytes.Repeat([]byte{0x24}, 16)
+	encrypt := func(plain []byte) []byte {
+		out, err := EncryptAESECB(plain, key, PaddingNone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	badPKCS7 := append(bytes.Repeat([]byte{'x'}, 13), 3, 2, 3)
+	tooLong := append(bytes.Repeat([]byte{'x'}, 15), 17)
+	zeroLen := bytes.Repeat([]byte{'x'}, 16)
+	zeroLen[15] = 0
+
+	var paddingErr PaddingError
+	var aesKeyErr aes.KeySizeError
+	var desKeyErr des.KeySizeError
+	var rc4KeyErr rc4.KeySizeError
+	tests := []struct {
+		name    string
+		f       func() ([]byte, error)
+		wantAs  interface{}
+		wantErr error
+	}{
+		{"PKCS#7 mismatch", func() ([]byte, error) { return DecryptAESECB(encrypt(badPKCS7), key, PaddingPKCS7) }, &paddingErr, nil},
+		{"PKCS#7 too long", func() ([]byte, error) { return DecryptAESECB(encrypt(tooLong), key, PaddingPKCS7) }, &paddingErr, nil},
+		{"PKCS#7 zero length", func() ([]byte, error) { return DecryptAESECB(encrypt(zeroLen), key, PaddingPKCS7) }, &paddingErr, nil},
+		{"PKCS#7 empty", func() ([]byte, error) { return DecryptAESECB(nil, key, PaddingPKCS7) }, &paddingErr, nil},
+		{"partial block", func() ([]byte, error) { return DecryptAESCBC(make([]byte, 17), key, iv, PaddingPKCS7) }, &paddingErr, nil},
+		{"no padding partial block", func() ([]byte, error) { return EncryptAESECB(make([]byte, 17), key, PaddingNone) }, &paddingErr, nil},
+		{"unknown padding", func() ([]byte, error) { return EncryptAESECB(make([]byte, 16), key, Padding(9)) }, &paddingErr, nil},
+		{"AES key size", func() ([]byte, error) { return DecryptAESECB(make([]byte, 16), key[:5], PaddingNone) }, &aesKeyErr, nil},
+		{"DES key size", func() ([]byte, error) { return DecryptDESECB(make([]byte, 8), key[:5], PaddingNone) }, &desKeyErr, nil},
+		{"RC4 key size", func() ([]byte, error) { return DecryptRC4(make([]byte, 8), nil) }, &rc4KeyErr, nil},
+		{"CBC IV size", func() ([]byte, error) { return DecryptAESCBC(make([]byte, 16), key, iv[:8], PaddingNone) }, nil, ErrIVSize},
+		{"CTR IV size", func() ([]byte, error) { return EncryptAESCTR(make([]byte, 16), key, nil) }, nil, ErrIVSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.f()
+			if got != nil {
+				t.Errorf("got %x, want nil", got)
+			}
+			if tt.wantAs != nil && !errors.As(err, tt.wantAs) {
+				t.Errorf("error = %v, want %T", err, tt.wantAs)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPaddingError_Error(t *testing.T) {
+	e := PaddingError{PaddingPKCS7, 16, 32, "invalid padding length 17"}
+	want := "invalid data for PKCS#7 padding with 16-byte blocks: invalid padding length 17"
+	if got := e.Error(); got != want {
+		t.Errorf("PaddingError.Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,336 @@
+package process
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/kaitai-io/kaitai_struct_go_runtime/kaitai"
+)
+
+const (
+	lz4FrameMagic       = 0x184d2204
+	lz4LegacyFrameMagic = 0x184c2102
+	lz4SkippableMagic   = 0x184d2a50 // to 0x184d2a5f
+	lz4LegacyBlockSize  = 8 << 20
+	lz4MinMatch         = 4
+)
+
+// LZ4Block decompresses a single LZ4 block, as stored inside LZ4 frames or
+// on its own in various formats. As a block doesn't record the size of its
+// decompressed data, maxSize should always be given.
+func LZ4Block(in []byte, maxSize int64) ([]byte, error) {
+	res, err := decodeLZ4Block(nil, in, 0, outputLimit(maxSize))
+	if err != nil {
+		return nil, fmt.Errorf("LZ4Block: %w", err)
+	}
+	return res, nil
+}
+
+// LZ4Frame decompresses data in the LZ4 frame format, as produced by the lz4
+// command line tool. Concatenated frames are decompressed as one, skippable
+// frames are ignored and the legacy frame format, which is used e.g. for
+// Linux kernel images, is supported as well. Checksums present in the
+// frames are verified.
+func LZ4Frame(in []byte, maxSize int64) ([]byte, error) {
+	limit := outputLimit(maxSize)
+	var out []byte
+	for pos := 0; pos < len(in); {
+		if len(in)-pos < 4 {
+			return nil, fmt.Errorf("LZ4Frame: %w", corruptf("truncated magic number at offset %d", pos))
+		}
+		magic := binary.LittleEndian.Uint32(in[pos:])
+		var err error
+		switch {
+		case magic == lz4FrameMagic:
+			out, pos, err = decodeLZ4Frame(out, in, pos+4, limit)
+		case magic == lz4LegacyFrameMagic:
+			out, pos, err = decodeLZ4LegacyFrame(out, in, pos+4, limit)
+		case magic&0xfffffff0 == lz4SkippableMagic:
+			if len(in)-pos < 8 {
+				err = corruptf("truncated skippable frame at offset %d", pos)
+				break
+			}
+			size := binary.LittleEndian.Uint32(in[pos+4:])
+			if uint64(size) > uint64(len(in)-pos-8) {
+				err = corruptf("truncated skippable frame at offset %d", pos)
+				break
+			}
+			pos += 8 + int(size)
+		default:
+			err = corruptf("unknown magic number 0x%08x at offset %d", magic, pos)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("LZ4Frame: %w", err)
+		}
+	}
+	return out, nil
+}
+
+// decodeLZ4Frame appends the data of the frame whose descriptor starts at
+// in[pos:] to out. It returns the extended out and the position after the
+// frame.
+func decodeLZ4Frame(out, in []byte, pos int, limit int) ([]byte, int, error) {
+	if len(in)-pos < 3 {
+		return out, pos, corruptf("truncated frame descriptor at offset %d", pos)
+	}
+	descStart := pos
+	flg, bd := in[pos], in[pos+1]
+	pos += 2
+	if flg>>6 != 1 {
+		return out, pos, corruptf("unknown frame version %d", flg>>6)
+	}
+	if flg&0x02 != 0 || bd&0x8f != 0 {
+		return out, pos, corruptf("reserved frame descriptor bits set")
+	}
+	blockIndep := flg&0x20 != 0
+	blockChecksum := flg&0x10 != 0
+	hasContentSize := flg&0x08 != 0
+	contentChecksum := flg&0x04 != 0
+	hasDictID := flg&0x01 != 0
+
+	blockMaxID := bd >> 4 & 0x7
+	if blockMaxID < 4 {
+		return out, pos, corruptf("invalid block maximum size id %d", blockMaxID)
+	}
+	blockMax := 1 << (8 + 2*blockMaxID)
+
+	var contentSize uint64
+	if hasContentSize {
+		if len(in)-pos < 8 {
+			return out, pos, corruptf("truncated frame descriptor at offset %d", descStart)
+		}
+		contentSize = binary.LittleEndian.Uint64(in[pos:])
+		pos += 8
+	}
+	if hasDictID {
+		return out, pos, fmt.Errorf("frame at offset %d needs a dictionary: %w", descStart-4, ErrUnsupported)
+	}
+	if len(in)-pos < 1 {
+		return out, pos, corruptf("truncated frame descriptor at offset %d", descStart)
+	}
+	if hc := byte(xxh32(in[descStart:pos], 0) >> 8); hc != in[pos] {
+		return out, pos, corruptf("frame descriptor checksum mismatch at offset %d", descStart)
+	}
+	pos++
+
+	frameStart := len(out)
+	for {
+		if len(in)-pos < 4 {
+			return out, pos, corruptf("truncated block size at offset %d", pos)
+		}
+		size := binary.LittleEndian.Uint32(in[pos:])
+		pos += 4
+		if size == 0 {
+			break
+		}
+		uncompressed := size&0x80000000 != 0
+		size &= 0x7fffffff
+		if int64(size) > int64(blockMax) {
+			return out, pos, corruptf("block size %d exceeds maximum %d", size, blockMax)
+		}
+		if int(size) > len(in)-pos {
+			return out, pos, corruptf("truncated block at offset %d", pos)
+		}
+		block := in[pos : pos+int(size)]
+		pos += int(size)
+		if blockChecksum {
+			if len(in)-pos < 4 {
+				return out, pos, corruptf("truncated block checksum at offset %d", pos)
+			}
+			if xxh32(block, 0) != binary.LittleEndian.Uint32(in[pos:]) {
+				return out, pos, corruptf("block checksum mismatch at offset %d", pos)
+			}
+			pos += 4
+		}
+
+		blockLimit := limit
+		if len(out)+blockMax < blockLimit {
+			blockLimit = len(out) + blockMax
+		}
+		if uncompressed {
+			if err := grow(out, len(block), limit); err != nil {
+				return out, pos, err
+			}
+			out = append(out, block...)
+			continue
+		}
+		histStart := frameStart
+		if blockIndep {
+			histStart = len(out)
+		}
+		var err error
+		if out, err = decodeLZ4Block(out, block, histStart, blockLimit); err != nil {
+			if errors.Is(err, kaitai.ErrMaxSizeExceeded) && blockLimit < limit {
+				err = corruptf("block decompresses to more than %d bytes", blockMax)
+			}
+			return out, pos, err
+		}
+	}
+
+	if contentChecksum {
+		if len(in)-pos < 4 {
+			return out, pos, corruptf("truncated content checksum at offset %d", pos)
+		}
+		if xxh32(out[frameStart:], 0) != binary.LittleEndian.Uint32(in[pos:]) {
+			return out, pos, corruptf("content checksum mismatch at offset %d", pos)
+		}
+		pos += 4
+	}
+	if hasContentSize && uint64(len(out)-frameStart) != contentSize {
+		return out, pos, corruptf("content size %d differs from the declared %d", len(out)-frameStart, contentSize)
+	}
+	return out, pos, nil
+}
+
+// decodeLZ4LegacyFrame appends the data of the legacy frame whose blocks
+// start at in[pos:] to out. A legacy frame ends at the end of the input or
+// at the magic number of the next frame.
+func decodeLZ4LegacyFrame(out, in []byte, pos int, limit int) ([]byte, int, error) {
+	for len(in)-pos >= 4 {
+		size := binary.LittleEndian.Uint32(in[pos:])
+		if size == lz4FrameMagic || size == lz4LegacyFrameMagic || size&0xfffffff0 == lz4SkippableMagic {
+			break
+		}
+		pos += 4
+		if uint64(size) > uint64(len(in)-pos) {
+			return out, pos, corruptf("truncated block at offset %d", pos)
+		}
+		blockLimit := limit
+		if len(out)+lz4LegacyBlockSize < blockLimit {
+			blockLimit = len(out) + lz4LegacyBlockSize
+		}
+		var err error
+		if out, err = decodeLZ4Block(out, in[pos:pos+int(size)], len(out), blockLimit); err != nil {
+			if errors.Is(err, kaitai.ErrMaxSizeExceeded) && blockLimit < limit {
+				err = corruptf("block decompresses to more than %d bytes", lz4LegacyBlockSize)
+			}
+			return out, pos, err
+		}
+		pos += int(size)
+	}
+	if pos != len(in) && len(in)-pos < 4 {
+		return out, pos, corruptf("truncated block size at offset %d", pos)
+	}
+	return out, pos, nil
+}
+
+// decodeLZ4Block appends the decompression of the block src to out, which
+// may grow to at most limit bytes. Matches may refer back to out[histStart:].
+func decodeLZ4Block(out, src []byte, histStart int, limit int) ([]byte, error) {
+	pos := 0
+	for {
+		if pos >= len(src) {
+			return out, corruptf("truncated sequence at block offset %d", pos)
+		}
+		token := src[pos]
+		pos++
+
+		litLen, n, err := lz4ExtendedLength(src[pos:], int(token>>4))
+		if err != nil {
+			return out, err
+		}
+		pos += n
+		if litLen > len(src)-pos {
+			return out, corruptf("literals at block offset %d exceed the block", pos)
+		}
+		if err := grow(out, litLen, limit); err != nil {
+			return out, err
+		}
+		out = append(out, src[pos:pos+litLen]...)
+		pos += litLen
+		if pos == len(src) {
+			// The last sequence consists of literals only
+			return out, nil
+		}
+
+		if len(src)-pos < 2 {
+			return out, corruptf("truncated match offset at block offset %d", pos)
+		}
+		offset := int(binary.LittleEndian.Uint16(src[pos:]))
+		pos += 2
+		if offset == 0 || offset > len(out)-histStart {
+			return out, corruptf("match offset %d at output position %d points outside the output", offset, len(out)-histStart)
+		}
+
+		matchLen, n, err := lz4ExtendedLength(src[pos:], int(token&0xf))
+		if err != nil {
+			return out, err
+		}
+		pos += n
+		matchLen += lz4MinMatch
+		if err := grow(out, matchLen, limit); err != nil {
+			return out, err
+		}
+		out = appendMatch(out, offset, matchLen)
+	}
+}
+
+// lz4ExtendedLength completes a literal or match length whose 4-bit part
+// from the token is l. It returns the length and the number of bytes of src
+// used.
+func lz4ExtendedLength(src []byte, l int) (int, int, error) {
+	if l != 15 {
+		return l, 0, nil
+	}
+	for n := 0; n < len(src); n++ {
+		l += int(src[n])
+		if src[n] != 255 {
+			return l, n + 1, nil
+		}
+	}
+	return 0, 0, corruptf("truncated length")
+}
+
+const (
+	xxhPrime1 uint32 = 2654435761
+	xxhPrime2 uint32 = 2246822519
+	xxhPrime3 uint32 = 3266489917
+	xxhPrime4 uint32 = 668265263
+	xxhPrime5 uint32 = 374761393
+)
+
+// xxh32 returns the 32-bit xxHash of b, which LZ4 uses for its checksums.
+func xxh32(b []byte, seed uint32) uint32 {
+	n := len(b)
+	var h uint32
+	if n >= 16 {
+		v1 := seed + xxhPrime1 + xxhPrime2
+		v2 := seed + xxhPrime2
+		v3 := seed
+		v4 := seed - xxhPrime1
+		for len(b) >= 16 {
+			v1 = xxh32Round(v1, binary.LittleEndian.Uint32(b[0:]))
+			v2 = xxh32Round(v2, binary.LittleEndian.Uint32(b[4:]))
+			v3 = xxh32Round(v3, binary.LittleEndian.Uint32(b[8:]))
+			v4 = xxh32Round(v4, binary.LittleEndian.Uint32(b[12:]))
+			b = b[16:]
+		}
+		h = bits.RotateLeft32(v1, 1) + bits.RotateLeft32(v2, 7) + bits.RotateLeft32(v3, 12) + bits.RotateLeft32(v4, 18)
+	} else {
+		h = seed + xxhPrime5
+	}
+	h += uint32(n)
+	for len(b) >= 4 {
+		h += binary.LittleEndian.Uint32(b) * xxhPrime3
+		h = bits.RotateLeft32(h, 17) * xxhPrime4
+		b = b[4:]
+	}
+	for _, c := range b {
+		h += uint32(c) * xxhPrime5
+		h = bits.RotateLeft32(h, 11) * xxhPrime1
+	}
+	h ^= h >> 15
+	h *= xxhPrime2
+	h ^= h >> 13
+	h *= xxhPrime3
+	h ^= h >> 16
+	return h
+}
+
+func xxh32Round(acc, input uint32) uint32 {
+	acc += input * xxhPrime2
+	acc = bits.RotateLeft32(acc, 13)
+	return acc * xxhPrime1
+}
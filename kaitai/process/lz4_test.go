@@ -0,0 +1,120 @@
+package process
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaitai-io/kaitai_struct_go_runtime/kaitai"
+)
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestLZ4Block(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []byte
+		maxSize int64
+		want    []byte
+		wantErr error
+	}{
+		{"literals only", []byte{0x50, 'h', 'e', 'l', 'l', 'o'}, 100, []byte("hello"), nil},
+		{"overlapping match", []byte{0x14, 'a', 0x01, 0x00, 0x10, 'b'}, 100, []byte("aaaaaaaaab"), nil},
+		{
+			"extended literal length", append([]byte{0xf0, 0x05}, bytes.Repeat([]byte{'x'}, 20)...), 100,
+			bytes.Repeat([]byte{'x'}, 20), nil,
+		},
+		{
+			"extended match length", []byte{0x1f, 'x', 0x01, 0x00, 0x02, 0x10, 'y'}, 100,
+			append(bytes.Repeat([]byte{'x'}, 22), 'y'), nil,
+		},
+		{"lz4 tool output", readTestdata(t, "x86.bin.lz4block"), 1 << 20, readTestdata(t, "x86.bin"), nil},
+		{"offset beyond output", []byte{0x14, 'a', 0x02, 0x00, 0x10, 'b'}, 100, nil, ErrCorrupt},
+		{"zero offset", []byte{0x14, 'a', 0x00, 0x00, 0x10, 'b'}, 100, nil, ErrCorrupt},
+		{"literals beyond input", []byte{0x50, 'h', 'e'}, 100, nil, ErrCorrupt},
+		{"truncated offset", []byte{0x14, 'a', 0x01}, 100, nil, ErrCorrupt},
+		{"truncated length", []byte{0xf0, 0xff}, 100, nil, ErrCorrupt},
+		{"empty", []byte{}, 100, nil, ErrCorrupt},
+		{"over limit", []byte{0x14, 'a', 0x01, 0x00, 0x10, 'b'}, 9, nil, kaitai.ErrMaxSizeExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LZ4Block(tt.in, tt.maxSize)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("LZ4Block() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("LZ4Block() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLZ4Frame(t *testing.T) {
+	text := readTestdata(t, "text.bin")
+	frame := readTestdata(t, "text.bin.lz4")
+	skippable := []byte{0x5a, 0x2a, 0x4d, 0x18, 0x03, 0x00, 0x00, 0x00, 1, 2, 3}
+
+	corruptChecksum := bytes.Clone(frame)
+	corruptChecksum[len(corruptChecksum)-1] ^= 0xff
+	corruptDescriptor := bytes.Clone(frame)
+	corruptDescriptor[6] ^= 0xff
+
+	tests := []struct {
+		name    string
+		in      []byte
+		maxSize int64
+		want    []byte
+		wantErr error
+	}{
+		{"independent blocks", frame, 0, text, nil},
+		{"linked blocks with checksums and content size", readTestdata(t, "text.bin.linked.lz4"), 0, text, nil},
+		{"legacy", readTestdata(t, "text.bin.legacy.lz4"), 0, text, nil},
+		{"concatenated with skippable frame", bytes.Join([][]byte{frame, skippable, frame}, nil), 0, append(bytes.Clone(text), text...), nil},
+		{"within limit", frame, int64(len(text)), text, nil},
+		{"over limit", frame, int64(len(text)) - 1, nil, kaitai.ErrMaxSizeExceeded},
+		{"content checksum mismatch", corruptChecksum, 0, nil, ErrCorrupt},
+		{"descriptor checksum mismatch", corruptDescriptor, 0, nil, ErrCorrupt},
+		{"truncated", frame[:len(frame)-10], 0, nil, ErrCorrupt},
+		{"unknown magic", []byte{1, 2, 3, 4}, 0, nil, ErrCorrupt},
+		{"dictionary", []byte{0x04, 0x22, 0x4d, 0x18, 0x61, 0x40, 0, 0, 0, 0, 0}, 0, nil, ErrUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LZ4Frame(tt.in, tt.maxSize)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("LZ4Frame() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("LZ4Frame() returned %d bytes, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func Test_xxh32(t *testing.T) {
+	tests := []struct {
+		in   string
+		seed uint32
+		want uint32
+	}{
+		{"", 0, 0x02cc5d05},
+		{"a", 0, 0x550d7456},
+		{"abc", 0, 0x32d153ff},
+		{"Nobody inspects the spammish repetition", 0, 0xe2293b2f},
+	}
+	for _, tt := range tests {
+		if got := xxh32([]byte(tt.in), tt.seed); got != tt.want {
+			t.Errorf("xxh32(%q, %d) = 0x%08x, want 0x%08x", tt.in, tt.seed, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package process
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// The LZMA decoder follows the structure of the reference decoder of the
+// LZMA SDK (LzmaSpec.cpp), decoding into a single output slice which also
+// serves as the dictionary.
+
+const (
+	lzmaNumStates       = 12
+	lzmaNumPosBitsMax   = 4
+	lzmaNumLenToPosStat = 4
+	lzmaNumAlignBits    = 4
+	lzmaStartPosModel   = 4
+	lzmaEndPosModel     = 14
+	lzmaNumFullDists    = 1 << (lzmaEndPosModel >> 1)
+	lzmaMatchMinLen     = 2
+	lzmaProbInit        = 1 << 10
+	lzmaMinDictSize     = 1 << 12
+)
+
+var errLzmaTruncated = corruptf("truncated range coder data")
+
+// rangeDecoder decodes the range coded bits of LZMA.
+type rangeDecoder struct {
+	in   []byte
+	pos  int
+	rng  uint32
+	code uint32
+	// Set once the decoder tried to read beyond in
+	overrun bool
+}
+
+func (rc *rangeDecoder) init(in []byte) error {
+	if len(in) < 5 {
+		return errLzmaTruncated
+	}
+	if in[0] != 0 {
+		return corruptf("invalid range coder initialization")
+	}
+	rc.in = in
+	rc.pos = 5
+	rc.rng = 0xffffffff
+	rc.code = binary.BigEndian.Uint32(in[1:])
+	rc.overrun = false
+	if rc.code == rc.rng {
+		return corruptf("invalid range coder initialization")
+	}
+	return nil
+}
+
+func (rc *rangeDecoder) normalize() {
+	if rc.rng < 1<<24 {
+		rc.rng <<= 8
+		var b byte
+		if rc.pos < len(rc.in) {
+			b = rc.in[rc.pos]
+		} else {
+			rc.overrun = true
+		}
+		rc.pos++
+		rc.code = rc.code<<8 | uint32(b)
+	}
+}
+
+func (rc *rangeDecoder) finishedOK() bool {
+	return rc.code == 0
+}
+
+func (rc *rangeDecoder) decodeBit(prob *uint16) uint32 {
+	bound := (rc.rng >> 11) * uint32(*prob)
+	var bit uint32
+	if rc.code < bound {
+		*prob += (1<<11 - *prob) >> 5
+		rc.rng = bound
+	} else {
+		*prob -= *prob >> 5
+		rc.code -= bound
+		rc.rng -= bound
+		bit = 1
+	}
+	rc.normalize()
+	return bit
+}
+
+func (rc *rangeDecoder) decodeDirectBits(n int) uint32 {
+	var res uint32
+	for ; n > 0; n-- {
+		rc.rng >>= 1
+		rc.code -= rc.rng
+		t := 0 - (rc.code >> 31)
+		rc.code += rc.rng & t
+		rc.normalize()
+		res = res<<1 + t + 1
+	}
+	return res
+}
+
+func (rc *rangeDecoder) bitTree(probs []uint16, numBits int) uint32 {
+	m := uint32(1)
+	for i := 0; i < numBits; i++ {
+		m = m<<1 + rc.decodeBit(&probs[m])
+	}
+	return m - 1<<numBits
+}
+
+func (rc *rangeDecoder) bitTreeReverse(probs []uint16, numBits int) uint32 {
+	m := uint32(1)
+	var sym uint32
+	for i := 0; i < numBits; i++ {
+		bit := rc.decodeBit(&probs[m])
+		m = m<<1 + bit
+		sym |= bit << i
+	}
+	return sym
+}
+
+func initProbs(probs []uint16) {
+	for i := range probs {
+		probs[i] = lzmaProbInit
+	}
+}
+
+type lzmaLenDecoder struct {
+	choice  uint16
+	choice2 uint16
+	low     [1 << lzmaNumPosBitsMax][1 << 3]uint16
+	mid     [1 << lzmaNumPosBitsMax][1 << 3]uint16
+	high    [1 << 8]uint16
+}
+
+func (ld *lzmaLenDecoder) init() {
+	ld.choice = lzmaProbInit
+	ld.choice2 = lzmaProbInit
+	initProbs(ld.high[:])
+	for i := range ld.low {
+		initProbs(ld.low[i][:])
+		initProbs(ld.mid[i][:])
+	}
+}
+
+func (ld *lzmaLenDecoder) decode(rc *rangeDecoder, posState uint32) uint32 {
+	if rc.decodeBit(&ld.choice) == 0 {
+		return rc.bitTree(ld.low[posState][:], 3)
+	}
+	if rc.decodeBit(&ld.choice2) == 0 {
+		return 8 + rc.bitTree(ld.mid[posState][:], 3)
+	}
+	return 16 + rc.bitTree(ld.high[:], 8)
+}
+
+// lzmaProps holds the literal context bits, literal position bits and
+// position bits of an LZMA stream.
+type lzmaProps struct {
+	lc, lp, pb uint
+}
+
+func decodeLzmaProps(d byte) (lzmaProps, error) {
+	if d >= 9*5*5 {
+		return lzmaProps{}, corruptf("invalid properties byte 0x%02x", d)
+	}
+	return lzmaProps{uint(d % 9), uint(d / 9 % 5), uint(d / 45)}, nil
+}
+
+// lzmaDecoder holds the state of an LZMA decoder. The output produced so far
+// is shared with the caller, so that LZMA2 can keep it across chunks.
+type lzmaDecoder struct {
+	props    lzmaProps
+	dictSize uint32
+	rc       rangeDecoder
+
+	literalProbs []uint16
+	posSlot      [lzmaNumLenToPosStat][1 << 6]uint16
+	posDecoders  [1 + lzmaNumFullDists - lzmaEndPosModel]uint16
+	align        [1 << lzmaNumAlignBits]uint16
+	isMatch      [lzmaNumStates << lzmaNumPosBitsMax]uint16
+	isRep        [lzmaNumStates]uint16
+	isRepG0      [lzmaNumStates]uint16
+	isRepG1      [lzmaNumStates]uint16
+	isRepG2      [lzmaNumStates]uint16
+	isRep0Long   [lzmaNumStates << lzmaNumPosBitsMax]uint16
+	lenDecoder   lzmaLenDecoder
+	repLenDec    lzmaLenDecoder
+
+	state uint32
+	reps  [4]uint32
+
+	// Output, of which out[dictStart:] is the current dictionary
+	out       []byte
+	dictStart int
+	limit     int
+}
+
+// setProps changes the properties and resets the state.
+func (d *lzmaDecoder) setProps(props lzmaProps) {
+	d.props = props
+	n := 0x300 << (props.lc + props.lp)
+	if cap(d.literalProbs) >= n {
+		d.literalProbs = d.literalProbs[:n]
+	} else {
+		d.literalProbs = make([]uint16, n)
+	}
+	d.resetState()
+}
+
+// resetState resets the probabilities, the state and the repeated
+// distances, keeping the properties and the dictionary.
+func (d *lzmaDecoder) resetState() {
+	initProbs(d.literalProbs)
+	for i := range d.posSlot {
+		initProbs(d.posSlot[i][:])
+	}
+	initProbs(d.posDecoders[:])
+	initProbs(d.align[:])
+	initProbs(d.isMatch[:])
+	initProbs(d.isRep[:])
+	initProbs(d.isRepG0[:])
+	initProbs(d.isRepG1[:])
+	initProbs(d.isRepG2[:])
+	initProbs(d.isRep0Long[:])
+	d.lenDecoder.init()
+	d.repLenDec.init()
+	d.state = 0
+	d.reps = [4]uint32{}
+}
+
+func (d *lzmaDecoder) decodeLiteral(state uint32, rep0 uint32) {
+	dict := d.out[d.dictStart:]
+	var prevByte byte
+	if len(dict) > 0 {
+		prevByte = dict[len(dict)-1]
+	}
+	totalPos := uint32(len(dict))
+	litState := ((totalPos & (1<<d.props.lp - 1)) << d.props.lc) + uint32(prevByte>>(8-d.props.lc))
+	probs := d.literalProbs[0x300*litState:]
+
+	symbol := uint32(1)
+	if state >= 7 {
+		matchByte := uint32(dict[len(dict)-int(rep0)-1])
+		for symbol < 0x100 {
+			matchBit := (matchByte >> 7) & 1
+			matchByte <<= 1
+			bit := d.rc.decodeBit(&probs[((1+matchBit)<<8)+symbol])
+			symbol = symbol<<1 | bit
+			if matchBit != bit {
+				break
+			}
+		}
+	}
+	for symbol < 0x100 {
+		symbol = symbol<<1 | d.rc.decodeBit(&probs[symbol])
+	}
+	d.out = append(d.out, byte(symbol))
+}
+
+func (d *lzmaDecoder) decodeDistance(length uint32) uint32 {
+	lenState := length
+	if lenState > lzmaNumLenToPosStat-1 {
+		lenState = lzmaNumLenToPosStat - 1
+	}
+	posSlot := d.rc.bitTree(d.posSlot[lenState][:], 6)
+	if posSlot < 4 {
+		return posSlot
+	}
+	numDirectBits := int(posSlot>>1) - 1
+	dist := (2 | posSlot&1) << numDirectBits
+	if posSlot < lzmaEndPosModel {
+		dist += d.rc.bitTreeReverse(d.posDecoders[dist-posSlot:], numDirectBits)
+	} else {
+		dist += d.rc.decodeDirectBits(numDirectBits-lzmaNumAlignBits) << lzmaNumAlignBits
+		dist += d.rc.bitTreeReverse(d.align[:], lzmaNumAlignBits)
+	}
+	return dist
+}
+
+// errLzmaEndMarker signals that decode stopped at an end marker.
+var errLzmaEndMarker = errors.New("end marker")
+
+// decode decodes up to unpackSize bytes, or until an end marker if
+// unpackSize is negative. If allowEndMarker is true, an end marker may also
+// follow the data of a known size. It returns errLzmaEndMarker if it stopped
+// at an end marker.
+func (d *lzmaDecoder) decode(unpackSize int64, allowEndMarker bool) error {
+	rc := &d.rc
+	state := d.state
+	rep0, rep1, rep2, rep3 := d.reps[0], d.reps[1], d.reps[2], d.reps[3]
+	defer func() {
+		d.state = state
+		d.reps = [4]uint32{rep0, rep1, rep2, rep3}
+	}()
+
+	sizeDefined := unpackSize >= 0
+	start := len(d.out)
+	pbMask := uint32(1)<<d.props.pb - 1
+	for {
+		if rc.overrun {
+			return errLzmaTruncated
+		}
+		remaining := unpackSize - int64(len(d.out)-start)
+		if sizeDefined && remaining == 0 && (!allowEndMarker || rc.finishedOK()) {
+			return nil
+		}
+
+		dictLen := len(d.out) - d.dictStart
+		posState := uint32(dictLen) & pbMask
+
+		if rc.decodeBit(&d.isMatch[state<<lzmaNumPosBitsMax+posState]) == 0 {
+			if sizeDefined && remaining == 0 {
+				return corruptf("data continues after the declared size")
+			}
+			if err := grow(d.out, 1, d.limit); err != nil {
+				return err
+			}
+			d.decodeLiteral(state, rep0)
+			switch {
+			case state < 4:
+				state = 0
+			case state < 10:
+				state -= 3
+			default:
+				state -= 6
+			}
+			continue
+		}
+
+		var length uint32
+		if rc.decodeBit(&d.isRep[state]) != 0 {
+			if sizeDefined && remaining == 0 {
+				return corruptf("data continues after the declared size")
+			}
+			if dictLen == 0 {
+				return corruptf("repeated match at the start of the dictionary")
+			}
+			if rc.decodeBit(&d.isRepG0[state]) == 0 {
+				if rc.decodeBit(&d.isRep0Long[state<<lzmaNumPosBitsMax+posState]) == 0 {
+					if int64(rep0) >= int64(dictLen) {
+						return corruptf("match distance %d at dictionary position %d points outside the dictionary", rep0, dictLen)
+					}
+					if state < 7 {
+						state = 9
+					} else {
+						state = 11
+					}
+					if err := grow(d.out, 1, d.limit); err != nil {
+						return err
+					}
+					d.out = append(d.out, d.out[len(d.out)-int(rep0)-1])
+					continue
+				}
+			} else {
+				var dist uint32
+				if rc.decodeBit(&d.isRepG1[state]) == 0 {
+					dist = rep1
+				} else {
+					if rc.decodeBit(&d.isRepG2[state]) == 0 {
+						dist = rep2
+					} else {
+						dist = rep3
+						rep3 = rep2
+					}
+					rep2 = rep1
+				}
+				rep1 = rep0
+				rep0 = dist
+			}
+			length = d.repLenDec.decode(rc, posState)
+			if state < 7 {
+				state = 8
+			} else {
+				state = 11
+			}
+		} else {
+			rep3 = rep2
+			rep2 = rep1
+			rep1 = rep0
+			length = d.lenDecoder.decode(rc, posState)
+			if state < 7 {
+				state = 7
+			} else {
+				state = 10
+			}
+			rep0 = d.decodeDistance(length)
+			if rep0 == 0xffffffff {
+				if rc.overrun {
+					return errLzmaTruncated
+				}
+				if !rc.finishedOK() {
+					return corruptf("data after the end marker")
+				}
+				if sizeDefined && remaining != 0 {
+					return corruptf("end marker before the declared size")
+				}
+				return errLzmaEndMarker
+			}
+			if sizeDefined && remaining == 0 {
+				return corruptf("data continues after the declared size")
+			}
+		}
+		if int64(rep0) >= int64(dictLen) || rep0 >= d.dictSize {
+			return corruptf("match distance %d at dictionary position %d points outside the dictionary", rep0, dictLen)
+		}
+
+		n := int(length) + lzmaMatchMinLen
+		if sizeDefined && int64(n) > remaining {
+			return corruptf("match exceeds the declared size")
+		}
+		if err := grow(d.out, n, d.limit); err != nil {
+			return err
+		}
+		d.out = appendMatch(d.out, int(rep0)+1, n)
+	}
+}
+
+// LZMA decompresses data in the .lzma format of LZMA Utils, also known as
+// LZMA_Alone, which consists of a 13-byte header followed by a raw LZMA
+// stream.
+func LZMA(in []byte, maxSize int64) ([]byte, error) {
+	if len(in) < 13 {
+		return nil, fmt.Errorf("LZMA: %w", corruptf("truncated header"))
+	}
+	props, err := decodeLzmaProps(in[0])
+	if err != nil {
+		return nil, fmt.Errorf("LZMA: %w", err)
+	}
+	dictSize := binary.LittleEndian.Uint32(in[1:])
+	if dictSize < lzmaMinDictSize {
+		dictSize = lzmaMinDictSize
+	}
+	unpackSize := int64(-1)
+	if size := binary.LittleEndian.Uint64(in[5:]); size != 0xffffffffffffffff {
+		if size > 1<<62 {
+			return nil, fmt.Errorf("LZMA: %w", corruptf("invalid uncompressed size %d", size))
+		}
+		unpackSize = int64(size)
+	}
+	limit := outputLimit(maxSize)
+	if unpackSize > int64(limit) {
+		return nil, fmt.Errorf("LZMA: uncompressed size %d: %w", unpackSize, errMaxSize(limit))
+	}
+
+	d := &lzmaDecoder{dictSize: dictSize, limit: limit}
+	d.setProps(props)
+	if err := d.rc.init(in[13:]); err != nil {
+		return nil, fmt.Errorf("LZMA: %w", err)
+	}
+	err = d.decode(unpackSize, true)
+	if err == nil && !d.rc.finishedOK() {
+		err = corruptf("range coder not finished at the end of the data")
+	}
+	if err != nil && !errors.Is(err, errLzmaEndMarker) {
+		return nil, fmt.Errorf("LZMA: %w", err)
+	}
+	return d.out, nil
+}
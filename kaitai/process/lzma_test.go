@@ -0,0 +1,95 @@
+package process
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/kaitai-io/kaitai_struct_go_runtime/kaitai"
+)
+
+func TestLZMA(t *testing.T) {
+	text := readTestdata(t, "text.bin")
+	stream := readTestdata(t, "text.bin.lzma")
+
+	withSize := func(size uint64) []byte {
+		b := bytes.Clone(stream)
+		binary.LittleEndian.PutUint64(b[5:], size)
+		return b
+	}
+	smallDict := bytes.Clone(stream)
+	binary.LittleEndian.PutUint32(smallDict[1:], 4096)
+	badProps := bytes.Clone(stream)
+	badProps[0] = 225
+	badRangeCoder := bytes.Clone(stream)
+	badRangeCoder[13] = 1
+
+	tests := []struct {
+		name    string
+		in      []byte
+		maxSize int64
+		want    []byte
+		wantErr error
+	}{
+		{"end marker", stream, 0, text, nil},
+		{"declared size and end marker", withSize(uint64(len(text))), 0, text, nil},
+		{"within limit", stream, int64(len(text)), text, nil},
+		{"over limit", stream, int64(len(text)) - 1, nil, kaitai.ErrMaxSizeExceeded},
+		{"declared size over limit", withSize(uint64(len(text))), 1000, nil, kaitai.ErrMaxSizeExceeded},
+		{"declared size too small", withSize(1000), 0, nil, ErrCorrupt},
+		{"declared size too large", withSize(uint64(len(text)) + 1), 0, nil, ErrCorrupt},
+		{"distance beyond dictionary size", smallDict, 0, nil, ErrCorrupt},
+		{"invalid properties", badProps, 0, nil, ErrCorrupt},
+		{"invalid range coder start", badRangeCoder, 0, nil, ErrCorrupt},
+		{"truncated", stream[:len(stream)-100], 0, nil, ErrCorrupt},
+		{"truncated header", stream[:12], 0, nil, ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LZMA(tt.in, tt.maxSize)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("LZMA() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("LZMA() returned %d bytes, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestLZMA2(t *testing.T) {
+	text := readTestdata(t, "text.bin")
+	stream := readTestdata(t, "text.bin.lzma2")
+
+	tests := []struct {
+		name     string
+		in       []byte
+		dictSize uint32
+		maxSize  int64
+		want     []byte
+		wantErr  error
+	}{
+		{"xz tool output", stream, 1 << 20, 0, text, nil},
+		{"uncompressed chunks", []byte{0x01, 0x00, 0x02, 'a', 'b', 'c', 0x02, 0x00, 0x00, 'd', 0x00}, 4096, 0, []byte("abcd"), nil},
+		{"empty", []byte{0x00}, 4096, 0, []byte{}, nil},
+		{"over limit", stream, 1 << 20, int64(len(text)) - 1, nil, kaitai.ErrMaxSizeExceeded},
+		{"distance beyond dictionary size", stream, 4096, 0, nil, ErrCorrupt},
+		{"missing dictionary reset", []byte{0x02, 0x00, 0x00, 'a', 0x00}, 4096, 0, nil, ErrCorrupt},
+		{"missing properties", []byte{0x01, 0x00, 0x00, 'a', 0x80, 0x00, 0x00, 0x00, 0x04, 0, 0, 0, 0, 0, 0x00}, 4096, 0, nil, ErrCorrupt},
+		{"invalid control byte", []byte{0x03, 0x00}, 4096, 0, nil, ErrCorrupt},
+		{"missing end of stream", stream[:len(stream)-1], 1 << 20, 0, nil, ErrCorrupt},
+		{"data after end of stream", append(bytes.Clone(stream), 0), 1 << 20, 0, nil, ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LZMA2(tt.in, tt.dictSize, tt.maxSize)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("LZMA2() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("LZMA2() = %d bytes, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package process implements decompression routines for formats which the
+// standard library doesn't cover: LZ4, Snappy, LZMA, LZMA2 and XZ. They are
+// written in pure Go, so using them doesn't require cgo. It also provides
+// decryption with common symmetric ciphers, built on the crypto packages,
+// along with the encryption needed for serialization.
+//
+// All routines work on complete byte slices, like the process functions of
+// package kaitai. As compressed data comes from untrusted files, the
+// decompressors never follow back-references pointing outside of the output
+// produced so far, and they accept a maxSize argument limiting the size of
+// the output. If maxSize is positive, producing more than maxSize bytes fails
+// with kaitai.ErrMaxSizeExceeded.
+package process
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kaitai-io/kaitai_struct_go_runtime/kaitai"
+)
+
+// ErrCorrupt is returned when the input is not valid compressed data.
+var ErrCorrupt = errors.New("corrupt input")
+
+// ErrUnsupported is returned when the input uses a valid, but unsupported
+// feature of its format, such as an external dictionary.
+var ErrUnsupported = errors.New("unsupported feature")
+
+// corruptf returns an error wrapping ErrCorrupt, describing the problem.
+func corruptf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), ErrCorrupt)
+}
+
+// outputLimit converts maxSize into a limit of the output length, which is
+// effectively unlimited if maxSize is not positive.
+func outputLimit(maxSize int64) int {
+	const maxInt = int(^uint(0) >> 1)
+	if maxSize <= 0 || maxSize > int64(maxInt) {
+		return maxInt
+	}
+	return int(maxSize)
+}
+
+// grow checks that n more bytes can be appended to out without exceeding
+// limit.
+func grow(out []byte, n int, limit int) error {
+	if n > limit-len(out) {
+		return kaitai.ErrMaxSizeExceeded
+	}
+	return nil
+}
+
+// appendMatch appends length bytes copied from distance bytes back in out.
+// The caller must have checked that the distance is within out. The ranges
+// may overlap, in which case the copied bytes repeat.
+func appendMatch(out []byte, distance, length int) []byte {
+	start := len(out) - distance
+	if distance >= length {
+		return append(out, out[start:start+length]...)
+	}
+	for i := 0; i < length; i++ {
+		out = append(out, out[start+i])
+	}
+	return out
+}
+
+// errMaxSize returns kaitai.ErrMaxSizeExceeded, annotated with limit.
+func errMaxSize(limit int) error {
+	return fmt.Errorf("limit %d: %w", limit, kaitai.ErrMaxSizeExceeded)
+}
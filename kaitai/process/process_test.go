@@ -0,0 +1,65 @@
+package process
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// TestDecoders_mutated checks that the decoders handle corrupted input
+// without crashing: failures return no data, output never exceeds maxSize,
+// and formats with checksums reject input which doesn't decode to the
+// original data.
+func TestDecoders_mutated(t *testing.T) {
+	lzma2 := func(in []byte, maxSize int64) ([]byte, error) { return LZMA2(in, 1<<20, maxSize) }
+	tests := []struct {
+		name   string
+		file   string
+		encode func([]byte) []byte
+		decode func([]byte, int64) ([]byte, error)
+		// Whether the format has a checksum of the decoded data
+		checked bool
+	}{
+		{"LZ4Block", "x86.bin.lz4block", nil, LZ4Block, false},
+		{"LZ4Frame", "text.bin.linked.lz4", nil, LZ4Frame, true},
+		{"LZMA", "text.bin.lzma", nil, LZMA, false},
+		{"LZMA2", "text.bin.lzma2", nil, lzma2, false},
+		{"Snappy", "text.bin", encodeSnappy, Snappy, false},
+		{"SnappyFramed", "x86.bin", frameSnappy, SnappyFramed, true},
+		{"XZ", "x86.bin.bcj.xz", nil, XZ, true},
+		{"XZ delta", "x86.bin.delta.xz", nil, XZ, true},
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := readTestdata(t, tt.file)
+			if tt.encode != nil {
+				orig = tt.encode(orig)
+			}
+			want, err := tt.decode(orig, 0)
+			if err != nil {
+				t.Fatalf("decoding the original input: %v", err)
+			}
+			maxSize := 2 * int64(len(want))
+
+			in := make([]byte, len(orig))
+			for i := 0; i < 200; i++ {
+				copy(in, orig)
+				for j := rnd.Intn(4); j >= 0; j-- {
+					in[rnd.Intn(len(in))] ^= byte(1 + rnd.Intn(255))
+				}
+				got, err := tt.decode(in, maxSize)
+				switch {
+				case err != nil:
+					if got != nil {
+						t.Errorf("decoding mutation %d returned %d bytes with error %v", i, len(got), err)
+					}
+				case int64(len(got)) > maxSize:
+					t.Errorf("decoding mutation %d returned %d bytes, more than maxSize %d", i, len(got), maxSize)
+				case tt.checked && !bytes.Equal(got, want):
+					t.Errorf("decoding mutation %d returned different data without error", i)
+				}
+			}
+		})
+	}
+}
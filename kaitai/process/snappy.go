@@ -0,0 +1,182 @@
+package process
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/kaitai-io/kaitai_struct_go_runtime/kaitai"
+)
+
+const (
+	snappyMaxFramedChunk = 65536
+
+	snappyChunkCompressed   = 0x00
+	snappyChunkUncompressed = 0x01
+	snappyChunkPadding      = 0xfe
+	snappyChunkStreamID     = 0xff
+)
+
+var snappyStreamID = []byte("sNaPpY")
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Snappy decompresses data in the Snappy block format, which starts with the
+// length of the decompressed data.
+func Snappy(in []byte, maxSize int64) ([]byte, error) {
+	res, err := decodeSnappy(in, outputLimit(maxSize))
+	if err != nil {
+		return nil, fmt.Errorf("Snappy: %w", err)
+	}
+	return res, nil
+}
+
+// SnappyFramed decompresses data in the Snappy framing format, as produced
+// by most Snappy command line tools and streaming APIs. The checksums of all
+// chunks are verified.
+func SnappyFramed(in []byte, maxSize int64) ([]byte, error) {
+	limit := outputLimit(maxSize)
+	var out []byte
+	for pos := 0; pos < len(in); {
+		if len(in)-pos < 4 {
+			return nil, fmt.Errorf("SnappyFramed: %w", corruptf("truncated chunk header at offset %d", pos))
+		}
+		chunkType := in[pos]
+		size := int(in[pos+1]) | int(in[pos+2])<<8 | int(in[pos+3])<<16
+		if size > len(in)-pos-4 {
+			return nil, fmt.Errorf("SnappyFramed: %w", corruptf("truncated chunk at offset %d", pos))
+		}
+		chunk := in[pos+4 : pos+4+size]
+		if pos == 0 && chunkType != snappyChunkStreamID {
+			return nil, fmt.Errorf("SnappyFramed: %w", corruptf("missing stream identifier"))
+		}
+
+		var data []byte
+		switch {
+		case chunkType == snappyChunkStreamID:
+			if !bytes.Equal(chunk, snappyStreamID) {
+				return nil, fmt.Errorf("SnappyFramed: %w", corruptf("invalid stream identifier at offset %d", pos))
+			}
+		case chunkType == snappyChunkCompressed, chunkType == snappyChunkUncompressed:
+			if size < 4 {
+				return nil, fmt.Errorf("SnappyFramed: %w", corruptf("chunk at offset %d too short", pos))
+			}
+			data = chunk[4:]
+			if chunkType == snappyChunkCompressed {
+				var err error
+				if data, err = decodeSnappy(data, snappyMaxFramedChunk); err != nil {
+					if errors.Is(err, kaitai.ErrMaxSizeExceeded) {
+						err = corruptf("decompressed chunk too long")
+					}
+					return nil, fmt.Errorf("SnappyFramed: chunk at offset %d: %w", pos, err)
+				}
+			} else if len(data) > snappyMaxFramedChunk {
+				return nil, fmt.Errorf("SnappyFramed: %w", corruptf("chunk at offset %d too long", pos))
+			}
+			if snappyMaskedCRC(data) != binary.LittleEndian.Uint32(chunk) {
+				return nil, fmt.Errorf("SnappyFramed: %w", corruptf("checksum mismatch in chunk at offset %d", pos))
+			}
+		case chunkType < 0x80:
+			return nil, fmt.Errorf("SnappyFramed: %w", corruptf("reserved unskippable chunk type 0x%02x at offset %d", chunkType, pos))
+		default:
+			// Skippable chunk, including padding
+		}
+
+		if err := grow(out, len(data), limit); err != nil {
+			return nil, fmt.Errorf("SnappyFramed: %w", err)
+		}
+		out = append(out, data...)
+		pos += 4 + size
+	}
+	return out, nil
+}
+
+// snappyMaskedCRC returns the checksum of data used by the framing format.
+func snappyMaskedCRC(data []byte) uint32 {
+	c := crc32.Checksum(data, castagnoliTable)
+	return (c>>15 | c<<17) + 0xa282ead8
+}
+
+// decodeSnappy decompresses the Snappy block src, whose decompressed length
+// may not exceed limit.
+func decodeSnappy(src []byte, limit int) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 || length > 0xffffffff {
+		return nil, corruptf("invalid decompressed length")
+	}
+	if length > uint64(limit) {
+		return nil, fmt.Errorf("decompressed length %d: %w", length, errMaxSize(limit))
+	}
+	// Don't trust the declared length for the allocation: each byte of input
+	// expands to at most 64 bytes
+	capacity := length
+	if max := uint64(len(src)) * 64; capacity > max {
+		capacity = max
+	}
+	out := make([]byte, 0, capacity)
+	pos := n
+	for pos < len(src) {
+		tag := src[pos]
+		pos++
+		var offset, l int
+		switch tag & 3 {
+		case 0:
+			l = int(tag >> 2)
+			if l >= 60 {
+				nb := l - 59
+				if len(src)-pos < nb {
+					return nil, corruptf("truncated literal length at offset %d", pos)
+				}
+				l = 0
+				for i := nb - 1; i >= 0; i-- {
+					l = l<<8 | int(src[pos+i])
+				}
+				pos += nb
+			}
+			l++
+			if l <= 0 || l > len(src)-pos {
+				return nil, corruptf("literal at offset %d exceeds the input", pos)
+			}
+			if uint64(len(out)+l) > length {
+				return nil, corruptf("data exceeds the declared length %d", length)
+			}
+			out = append(out, src[pos:pos+l]...)
+			pos += l
+			continue
+		case 1:
+			if len(src)-pos < 1 {
+				return nil, corruptf("truncated copy at offset %d", pos)
+			}
+			l = 4 + int(tag>>2&7)
+			offset = int(tag&0xe0)<<3 | int(src[pos])
+			pos++
+		case 2:
+			if len(src)-pos < 2 {
+				return nil, corruptf("truncated copy at offset %d", pos)
+			}
+			l = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[pos:]))
+			pos += 2
+		case 3:
+			if len(src)-pos < 4 {
+				return nil, corruptf("truncated copy at offset %d", pos)
+			}
+			l = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[pos:]))
+			pos += 4
+		}
+		if offset <= 0 || offset > len(out) {
+			return nil, corruptf("copy offset %d at output position %d points outside the output", offset, len(out))
+		}
+		if uint64(len(out)+l) > length {
+			return nil, corruptf("data exceeds the declared length %d", length)
+		}
+		out = appendMatch(out, offset, l)
+	}
+	if uint64(len(out)) != length {
+		return nil, corruptf("data length %d differs from the declared %d", len(out), length)
+	}
+	return out, nil
+}
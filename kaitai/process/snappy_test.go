@@ -0,0 +1,175 @@
+package process
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/kaitai-io/kaitai_struct_go_runtime/kaitai"
+)
+
+func TestSnappy(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []byte
+		maxSize int64
+		want    []byte
+		wantErr error
+	}{
+		{"empty", []byte{0x00}, 0, []byte{}, nil},
+		{
+			"2-byte offset copy", append(append([]byte{0x18, 0x14}, "hello "...), 0x42, 0x06, 0x00, 0x00, '!'), 0,
+			[]byte("hello hello hello hello!"), nil,
+		},
+		{"1-byte offset copy", []byte{0x0c, 0x0c, 'a', 'b', 'c', 'd', 0x11, 0x04}, 0, []byte("abcdabcdabcd"), nil},
+		{"4-byte offset copy", []byte{0x06, 0x08, 'x', 'y', 'z', 0x0b, 0x03, 0x00, 0x00, 0x00}, 0, []byte("xyzxyz"), nil},
+		{
+			"long literal", append([]byte{0x64, 0xf0, 0x63}, bytes.Repeat([]byte{'a'}, 100)...), 0,
+			bytes.Repeat([]byte{'a'}, 100), nil,
+		},
+		{"offset beyond output", []byte{0x0c, 0x0c, 'a', 'b', 'c', 'd', 0x11, 0x05}, 0, nil, ErrCorrupt},
+		{"zero offset", []byte{0x0c, 0x0c, 'a', 'b', 'c', 'd', 0x11, 0x00}, 0, nil, ErrCorrupt},
+		{"shorter than declared", []byte{0x0d, 0x0c, 'a', 'b', 'c', 'd', 0x11, 0x04}, 0, nil, ErrCorrupt},
+		{"longer than declared", []byte{0x0b, 0x0c, 'a', 'b', 'c', 'd', 0x11, 0x04}, 0, nil, ErrCorrupt},
+		{"truncated literal", []byte{0x04, 0x0c, 'a'}, 0, nil, ErrCorrupt},
+		{"declared length over limit", []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, 1 << 20, nil, kaitai.ErrMaxSizeExceeded},
+		{"invalid length", []byte{0xff}, 0, nil, ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Snappy(tt.in, tt.maxSize)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("Snappy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Snappy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnappyFramed(t *testing.T) {
+	// Stream identifier, compressed chunk, padding chunk and uncompressed
+	// chunk, with checksums computed independently
+	framed := []byte{
+		0xff, 0x06, 0x00, 0x00, 0x73, 0x4e, 0x61, 0x50, 0x70, 0x59,
+		0x00, 0x11, 0x00, 0x00, 0x23, 0x73, 0x25, 0xeb, 0x18, 0x14, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x20,
+		0x42, 0x06, 0x00, 0x00, 0x21,
+		0xfe, 0x02, 0x00, 0x00, 0x00, 0x00,
+		0x01, 0x10, 0x00, 0x00, 0xdc, 0xf2, 0x34, 0xfb, 0x75, 0x6e, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65,
+		0x73, 0x73, 0x65, 0x64,
+	}
+	want := []byte("hello hello hello hello!uncompressed")
+
+	badChecksum := bytes.Clone(framed)
+	badChecksum[14] ^= 0xff
+	reserved := append(bytes.Clone(framed), 0x02, 0x00, 0x00, 0x00)
+
+	tests := []struct {
+		name    string
+		in      []byte
+		maxSize int64
+		want    []byte
+		wantErr error
+	}{
+		{"chunks", framed, 0, want, nil},
+		{"concatenated streams", append(bytes.Clone(framed), framed...), 0, append(bytes.Clone(want), want...), nil},
+		{"over limit", framed, int64(len(want)) - 1, nil, kaitai.ErrMaxSizeExceeded},
+		{"checksum mismatch", badChecksum, 0, nil, ErrCorrupt},
+		{"reserved chunk", reserved, 0, nil, ErrCorrupt},
+		{"missing stream identifier", framed[10:], 0, nil, ErrCorrupt},
+		{"truncated", framed[:len(framed)-1], 0, nil, ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SnappyFramed(tt.in, tt.maxSize)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("SnappyFramed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("SnappyFramed() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// encodeSnappy compresses src into the Snappy block format with a simple
+// greedy matcher, using all kinds of literals and 1- and 2-byte offset
+// copies.
+func encodeSnappy(src []byte) []byte {
+	dst := binary.AppendUvarint(nil, uint64(len(src)))
+	lit := 0
+	emitLiteral := func(end int) {
+		for lit < end {
+			n := min(end-lit, 1<<16)
+			switch {
+			case n <= 60:
+				dst = append(dst, byte(n-1)<<2)
+			case n <= 256:
+				dst = append(dst, 60<<2, byte(n-1))
+			default:
+				dst = append(dst, 61<<2, byte(n-1), byte((n-1)>>8))
+			}
+			dst = append(dst, src[lit:lit+n]...)
+			lit += n
+		}
+	}
+	table := make(map[uint32]int)
+	for i := 0; i+4 <= len(src); {
+		key := binary.LittleEndian.Uint32(src[i:])
+		cand, ok := table[key]
+		table[key] = i
+		if !ok || i-cand > 0xffff {
+			i++
+			continue
+		}
+		l := 4
+		for i+l < len(src) && src[cand+l] == src[i+l] {
+			l++
+		}
+		emitLiteral(i)
+		off := i - cand
+		for rem := l; rem > 0; {
+			n := min(rem, 64)
+			if n >= 4 && n <= 11 && off < 2048 {
+				dst = append(dst, byte(off>>8)<<5|byte(n-4)<<2|1, byte(off))
+			} else {
+				dst = append(dst, byte(n-1)<<2|2, byte(off), byte(off>>8))
+			}
+			rem -= n
+		}
+		i += l
+		lit = i
+	}
+	emitLiteral(len(src))
+	return dst
+}
+
+// frameSnappy compresses src into the Snappy framing format.
+func frameSnappy(src []byte) []byte {
+	dst := append([]byte{snappyChunkStreamID, 6, 0, 0}, snappyStreamID...)
+	for len(src) > 0 {
+		n := min(len(src), snappyMaxFramedChunk)
+		chunk := binary.LittleEndian.AppendUint32(nil, snappyMaskedCRC(src[:n]))
+		chunk = append(chunk, encodeSnappy(src[:n])...)
+		dst = append(dst, snappyChunkCompressed, byte(len(chunk)), byte(len(chunk)>>8), byte(len(chunk)>>16))
+		dst = append(dst, chunk...)
+		src = src[n:]
+	}
+	return dst
+}
+
+func TestSnappy_roundTrip(t *testing.T) {
+	for _, name := range []string{"text.bin", "x86.bin"} {
+		t.Run(name, func(t *testing.T) {
+			want := readTestdata(t, name)
+			if got, err := Snappy(encodeSnappy(want), 0); err != nil || !bytes.Equal(got, want) {
+				t.Errorf("Snappy() = %d bytes, %v, want %d bytes", len(got), err, len(want))
+			}
+			if got, err := SnappyFramed(frameSnappy(want), 0); err != nil || !bytes.Equal(got, want) {
+				t.Errorf("SnappyFramed() = %d bytes, %v, want %d bytes", len(got), err, len(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,506 @@
+package process
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"hash/crc64"
+)
+
+var (
+	xzHeaderMagic = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	xzFooterMagic = []byte{'Y', 'Z'}
+
+	crc64Table = crc64.MakeTable(crc64.ECMA)
+)
+
+const (
+	xzCheckNone   = 0x00
+	xzCheckCRC32  = 0x01
+	xzCheckCRC64  = 0x04
+	xzCheckSHA256 = 0x0a
+
+	xzFilterDelta = 0x03
+	xzFilterX86   = 0x04
+	xzFilterLZMA2 = 0x21
+
+	xzMaxFilters = 4
+)
+
+// xzCheckSizes maps the check types of the .xz format to the size of the
+// check field.
+var xzCheckSizes = [16]int{0, 4, 4, 4, 8, 8, 8, 16, 16, 16, 32, 32, 32, 64, 64, 64}
+
+// LZMA2 decompresses a raw LZMA2 stream, as used inside .xz files and 7z
+// archives, using a dictionary of dictSize bytes.
+func LZMA2(in []byte, dictSize uint32, maxSize int64) ([]byte, error) {
+	out, n, err := decodeLzma2(nil, in, dictSize, outputLimit(maxSize))
+	if err != nil {
+		return nil, fmt.Errorf("LZMA2: %w", err)
+	}
+	if n != len(in) {
+		return nil, fmt.Errorf("LZMA2: %w", corruptf("data after the end of the stream at offset %d", n))
+	}
+	return out, nil
+}
+
+// decodeLzma2 appends the decompression of the LZMA2 stream at the start of
+// in to out. It returns the extended out and the length of the stream.
+func decodeLzma2(out, in []byte, dictSize uint32, limit int) ([]byte, int, error) {
+	d := &lzmaDecoder{dictSize: dictSize, limit: limit, out: out, dictStart: len(out)}
+	needDictReset, needProps := true, true
+	pos := 0
+	for {
+		if pos >= len(in) {
+			return d.out, pos, corruptf("truncated LZMA2 stream")
+		}
+		control := in[pos]
+		pos++
+		if control == 0x00 {
+			return d.out, pos, nil
+		}
+
+		if control >= 0xe0 || control == 0x01 {
+			needProps = true
+			needDictReset = false
+			d.dictStart = len(d.out)
+		} else if needDictReset {
+			return d.out, pos, corruptf("missing dictionary reset in LZMA2 chunk at offset %d", pos-1)
+		}
+
+		if control < 0x80 {
+			if control > 0x02 {
+				return d.out, pos, corruptf("invalid LZMA2 control byte 0x%02x at offset %d", control, pos-1)
+			}
+			if len(in)-pos < 2 {
+				return d.out, pos, corruptf("truncated LZMA2 chunk header")
+			}
+			size := int(binary.BigEndian.Uint16(in[pos:])) + 1
+			pos += 2
+			if size > len(in)-pos {
+				return d.out, pos, corruptf("truncated LZMA2 chunk at offset %d", pos)
+			}
+			if err := grow(d.out, size, limit); err != nil {
+				return d.out, pos, err
+			}
+			d.out = append(d.out, in[pos:pos+size]...)
+			pos += size
+			continue
+		}
+
+		if len(in)-pos < 4 {
+			return d.out, pos, corruptf("truncated LZMA2 chunk header")
+		}
+		unpacked := int(control&0x1f)<<16 + int(binary.BigEndian.Uint16(in[pos:])) + 1
+		packed := int(binary.BigEndian.Uint16(in[pos+2:])) + 1
+		pos += 4
+		switch {
+		case control >= 0xc0:
+			if pos >= len(in) {
+				return d.out, pos, corruptf("truncated LZMA2 chunk header")
+			}
+			props, err := decodeLzmaProps(in[pos])
+			if err != nil {
+				return d.out, pos, err
+			}
+			if props.lc+props.lp > 4 {
+				return d.out, pos, corruptf("invalid LZMA2 properties byte 0x%02x", in[pos])
+			}
+			pos++
+			d.setProps(props)
+			needProps = false
+		case needProps:
+			return d.out, pos, corruptf("missing properties in LZMA2 chunk")
+		case control >= 0xa0:
+			d.resetState()
+		}
+
+		if packed > len(in)-pos {
+			return d.out, pos, corruptf("truncated LZMA2 chunk at offset %d", pos)
+		}
+		if err := d.rc.init(in[pos : pos+packed]); err != nil {
+			return d.out, pos, err
+		}
+		if err := d.decode(int64(unpacked), false); err != nil {
+			return d.out, pos, err
+		}
+		if d.rc.overrun || !d.rc.finishedOK() || d.rc.pos != packed {
+			return d.out, pos, corruptf("LZMA2 chunk at offset %d doesn't match its compressed size", pos)
+		}
+		pos += packed
+	}
+}
+
+// XZ decompresses data in the .xz format. Concatenated streams and stream
+// padding are supported. The integrity checks of the blocks are verified if
+// they use CRC32, CRC64 or SHA-256, and ignored otherwise. Besides LZMA2,
+// blocks may use the delta and x86 BCJ filters, the latter being common in
+// Linux kernel images.
+func XZ(in []byte, maxSize int64) ([]byte, error) {
+	limit := outputLimit(maxSize)
+	var out []byte
+	pos := 0
+	for {
+		var err error
+		out, pos, err = decodeXZStream(out, in, pos, limit)
+		if err != nil {
+			return nil, fmt.Errorf("XZ: %w", err)
+		}
+		padStart := pos
+		for pos < len(in) && in[pos] == 0 {
+			pos++
+		}
+		if (pos-padStart)%4 != 0 {
+			return nil, fmt.Errorf("XZ: %w", corruptf("invalid stream padding at offset %d", padStart))
+		}
+		if pos == len(in) {
+			return out, nil
+		}
+	}
+}
+
+type xzIndexRecord struct {
+	unpaddedSize     uint64
+	uncompressedSize uint64
+}
+
+// decodeXZStream appends the data of the .xz stream starting at in[pos:] to
+// out. It returns the extended out and the position after the stream.
+func decodeXZStream(out, in []byte, pos int, limit int) ([]byte, int, error) {
+	if len(in)-pos < 12 {
+		return out, pos, corruptf("truncated stream header at offset %d", pos)
+	}
+	if !bytes.Equal(in[pos:pos+6], xzHeaderMagic) {
+		return out, pos, corruptf("invalid stream header magic at offset %d", pos)
+	}
+	flags := in[pos+6 : pos+8]
+	if crc32.ChecksumIEEE(flags) != binary.LittleEndian.Uint32(in[pos+8:]) {
+		return out, pos, corruptf("stream header checksum mismatch at offset %d", pos)
+	}
+	if flags[0] != 0 || flags[1]&0xf0 != 0 {
+		return out, pos, fmt.Errorf("stream flags 0x%02x%02x at offset %d: %w", flags[0], flags[1], pos, ErrUnsupported)
+	}
+	checkType := flags[1]
+	pos += 12
+
+	var records []xzIndexRecord
+	for {
+		if pos >= len(in) {
+			return out, pos, corruptf("truncated stream")
+		}
+		if in[pos] == 0 {
+			break
+		}
+		var rec xzIndexRecord
+		var err error
+		out, pos, rec, err = decodeXZBlock(out, in, pos, checkType, limit)
+		if err != nil {
+			return out, pos, err
+		}
+		records = append(records, rec)
+	}
+
+	indexStart := pos
+	pos++
+	count, n := xzVarint(in[pos:])
+	if n == 0 || count != uint64(len(records)) {
+		return out, pos, corruptf("index at offset %d doesn't match the blocks", indexStart)
+	}
+	pos += n
+	for _, rec := range records {
+		unpadded, n1 := xzVarint(in[pos:])
+		if n1 == 0 {
+			return out, pos, corruptf("invalid index record at offset %d", pos)
+		}
+		uncompressed, n2 := xzVarint(in[pos+n1:])
+		if n2 == 0 || unpadded != rec.unpaddedSize || uncompressed != rec.uncompressedSize {
+			return out, pos, corruptf("index record at offset %d doesn't match its block", pos)
+		}
+		pos += n1 + n2
+	}
+	for (pos-indexStart)%4 != 0 {
+		if pos >= len(in) || in[pos] != 0 {
+			return out, pos, corruptf("invalid index padding at offset %d", pos)
+		}
+		pos++
+	}
+	if len(in)-pos < 4 {
+		return out, pos, corruptf("truncated index")
+	}
+	if crc32.ChecksumIEEE(in[indexStart:pos]) != binary.LittleEndian.Uint32(in[pos:]) {
+		return out, pos, corruptf("index checksum mismatch at offset %d", indexStart)
+	}
+	pos += 4
+	indexSize := pos - indexStart
+
+	if len(in)-pos < 12 {
+		return out, pos, corruptf("truncated stream footer at offset %d", pos)
+	}
+	footer := in[pos : pos+12]
+	if crc32.ChecksumIEEE(footer[4:10]) != binary.LittleEndian.Uint32(footer) {
+		return out, pos, corruptf("stream footer checksum mismatch at offset %d", pos)
+	}
+	if (int64(binary.LittleEndian.Uint32(footer[4:]))+1)*4 != int64(indexSize) {
+		return out, pos, corruptf("stream footer at offset %d doesn't match the index size", pos)
+	}
+	if !bytes.Equal(footer[8:10], flags) || !bytes.Equal(footer[10:], xzFooterMagic) {
+		return out, pos, corruptf("invalid stream footer at offset %d", pos)
+	}
+	return out, pos + 12, nil
+}
+
+type xzFilter struct {
+	id    uint64
+	props []byte
+}
+
+// decodeXZBlock appends the data of the block starting at in[pos:] to out.
+// It returns the extended out, the position after the block and the block's
+// index record.
+func decodeXZBlock(out, in []byte, pos int, checkType byte, limit int) ([]byte, int, xzIndexRecord, error) {
+	var rec xzIndexRecord
+	blockStart := pos
+	headerSize := (int(in[pos]) + 1) * 4
+	if headerSize > len(in)-pos {
+		return out, pos, rec, corruptf("truncated block header at offset %d", pos)
+	}
+	header := in[pos : pos+headerSize-4]
+	if crc32.ChecksumIEEE(header) != binary.LittleEndian.Uint32(in[pos+headerSize-4:]) {
+		return out, pos, rec, corruptf("block header checksum mismatch at offset %d", pos)
+	}
+	flags := header[1]
+	if flags&0x3c != 0 {
+		return out, pos, rec, fmt.Errorf("block flags 0x%02x at offset %d: %w", flags, pos, ErrUnsupported)
+	}
+	p := 2
+	compressedSize, uncompressedSize := int64(-1), int64(-1)
+	for _, f := range []struct {
+		bit  byte
+		size *int64
+	}{{0x40, &compressedSize}, {0x80, &uncompressedSize}} {
+		if flags&f.bit == 0 {
+			continue
+		}
+		v, n := xzVarint(header[p:])
+		if n == 0 || v > 1<<62 {
+			return out, pos, rec, corruptf("invalid block header at offset %d", pos)
+		}
+		*f.size = int64(v)
+		p += n
+	}
+
+	filters := make([]xzFilter, int(flags&3)+1)
+	for i := range filters {
+		id, n1 := xzVarint(header[p:])
+		if n1 == 0 {
+			return out, pos, rec, corruptf("invalid filter flags at offset %d", pos+p)
+		}
+		propsSize, n2 := xzVarint(header[p+n1:])
+		if n2 == 0 || propsSize > uint64(len(header)-p-n1-n2) {
+			return out, pos, rec, corruptf("invalid filter flags at offset %d", pos+p)
+		}
+		p += n1 + n2
+		filters[i] = xzFilter{id, header[p : p+int(propsSize)]}
+		p += int(propsSize)
+	}
+	for _, b := range header[p:] {
+		if b != 0 {
+			return out, pos, rec, corruptf("invalid block header padding at offset %d", pos)
+		}
+	}
+
+	last := filters[len(filters)-1]
+	if last.id != xzFilterLZMA2 {
+		return out, pos, rec, fmt.Errorf("filter 0x%x at the end of the chain at offset %d: %w", last.id, pos, ErrUnsupported)
+	}
+	if len(last.props) != 1 || last.props[0] > 40 {
+		return out, pos, rec, corruptf("invalid LZMA2 properties at offset %d", pos)
+	}
+	dictSize := uint32(0xffffffff)
+	if bits := last.props[0]; bits < 40 {
+		dictSize = (2 | uint32(bits)&1) << (bits/2 + 11)
+	}
+	for _, f := range filters[:len(filters)-1] {
+		if err := checkXZFilter(f); err != nil {
+			return out, pos, rec, fmt.Errorf("block at offset %d: %w", pos, err)
+		}
+	}
+	pos += headerSize
+
+	data := in[pos:]
+	if compressedSize >= 0 {
+		if compressedSize > int64(len(data)) {
+			return out, pos, rec, corruptf("truncated block at offset %d", blockStart)
+		}
+		data = data[:compressedSize]
+	}
+	dataStart := len(out)
+	out, n, err := decodeLzma2(out, data, dictSize, limit)
+	if err != nil {
+		return out, pos, rec, fmt.Errorf("block at offset %d: %w", blockStart, err)
+	}
+	if compressedSize >= 0 && int64(n) != compressedSize {
+		return out, pos, rec, corruptf("block at offset %d doesn't match its compressed size", blockStart)
+	}
+	if uncompressedSize >= 0 && int64(len(out)-dataStart) != uncompressedSize {
+		return out, pos, rec, corruptf("block at offset %d doesn't match its uncompressed size", blockStart)
+	}
+	pos += n
+
+	for i := len(filters) - 2; i >= 0; i-- {
+		decodeXZFilter(filters[i], out[dataStart:])
+	}
+
+	checkSize := xzCheckSizes[checkType]
+	rec.unpaddedSize = uint64(headerSize + n + checkSize)
+	rec.uncompressedSize = uint64(len(out) - dataStart)
+	for (pos-blockStart)%4 != 0 {
+		if pos >= len(in) || in[pos] != 0 {
+			return out, pos, rec, corruptf("invalid block padding at offset %d", pos)
+		}
+		pos++
+	}
+	if len(in)-pos < checkSize {
+		return out, pos, rec, corruptf("truncated block check at offset %d", pos)
+	}
+	if err := verifyXZCheck(checkType, out[dataStart:], in[pos:pos+checkSize]); err != nil {
+		return out, pos, rec, fmt.Errorf("block at offset %d: %w", blockStart, err)
+	}
+	return out, pos + checkSize, rec, nil
+}
+
+func verifyXZCheck(checkType byte, data, check []byte) error {
+	var ok bool
+	switch checkType {
+	case xzCheckCRC32:
+		ok = crc32.ChecksumIEEE(data) == binary.LittleEndian.Uint32(check)
+	case xzCheckCRC64:
+		ok = crc64.Checksum(data, crc64Table) == binary.LittleEndian.Uint64(check)
+	case xzCheckSHA256:
+		sum := sha256.Sum256(data)
+		ok = bytes.Equal(sum[:], check)
+	default:
+		// No check, or one which isn't supported and can only be skipped
+		ok = true
+	}
+	if !ok {
+		return corruptf("integrity check mismatch")
+	}
+	return nil
+}
+
+// xzVarint decodes a variable-length integer of the .xz format. It returns
+// the value and the number of bytes read, which is 0 if b doesn't start with
+// a valid integer.
+func xzVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b) && i < 9; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i]&0x80 == 0 {
+			if i > 0 && b[i] == 0 {
+				return 0, 0
+			}
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+func checkXZFilter(f xzFilter) error {
+	switch f.id {
+	case xzFilterDelta:
+		if len(f.props) != 1 {
+			return corruptf("invalid delta filter properties")
+		}
+	case xzFilterX86:
+		if len(f.props) != 0 && len(f.props) != 4 {
+			return corruptf("invalid x86 filter properties")
+		}
+	default:
+		return fmt.Errorf("filter 0x%x: %w", f.id, ErrUnsupported)
+	}
+	return nil
+}
+
+// decodeXZFilter reverses the filter f, checked by checkXZFilter, in place.
+func decodeXZFilter(f xzFilter, data []byte) {
+	switch f.id {
+	case xzFilterDelta:
+		dist := int(f.props[0]) + 1
+		for i := dist; i < len(data); i++ {
+			data[i] += data[i-dist]
+		}
+	case xzFilterX86:
+		var startOffset uint32
+		if len(f.props) == 4 {
+			startOffset = binary.LittleEndian.Uint32(f.props)
+		}
+		decodeX86(data, startOffset)
+	}
+}
+
+// decodeX86 reverses the x86 BCJ filter, which converts the relative
+// addresses of CALL and JMP instructions to absolute ones for better
+// compression. It is a port of x86_code from liblzma.
+func decodeX86(buf []byte, startOffset uint32) {
+	maskToAllowed := [8]bool{true, true, true, false, true, false, false, false}
+	maskToBitNumber := [8]uint32{0, 1, 2, 2, 3, 3, 3, 3}
+	test86MSByte := func(b byte) bool { return b == 0x00 || b == 0xff }
+
+	if len(buf) < 5 {
+		return
+	}
+	var prevMask uint32
+	prevPos := startOffset - 5
+	limit := len(buf) - 5
+	for i := 0; i <= limit; {
+		b := buf[i]
+		if b != 0xe8 && b != 0xe9 {
+			i++
+			continue
+		}
+		nowPos := startOffset + uint32(i)
+		offset := nowPos - prevPos
+		prevPos = nowPos
+		if offset > 5 {
+			prevMask = 0
+		} else {
+			for j := uint32(0); j < offset; j++ {
+				prevMask &= 0x77
+				prevMask <<= 1
+			}
+		}
+
+		b = buf[i+4]
+		if test86MSByte(b) && maskToAllowed[(prevMask>>1)&7] && prevMask>>1 < 0x10 {
+			src := uint32(b)<<24 | uint32(buf[i+3])<<16 | uint32(buf[i+2])<<8 | uint32(buf[i+1])
+			var dest uint32
+			for {
+				dest = src - (nowPos + 5)
+				if prevMask == 0 {
+					break
+				}
+				idx := maskToBitNumber[prevMask>>1]
+				b = byte(dest >> (24 - idx*8))
+				if !test86MSByte(b) {
+					break
+				}
+				src = dest ^ (1<<(32-idx*8) - 1)
+			}
+			buf[i+4] = ^byte((dest>>24)&1 - 1)
+			buf[i+3] = byte(dest >> 16)
+			buf[i+2] = byte(dest >> 8)
+			buf[i+1] = byte(dest)
+			i += 5
+			prevMask = 0
+		} else {
+			i++
+			prevMask |= 1
+			if test86MSByte(b) {
+				prevMask |= 0x10
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package process
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/kaitai-io/kaitai_struct_go_runtime/kaitai"
+)
+
+func TestXZ(t *testing.T) {
+	text := readTestdata(t, "text.bin")
+	x86 := readTestdata(t, "x86.bin")
+	stream := readTestdata(t, "text.bin.crc32.xz")
+
+	// The check of the only block immediately precedes the index
+	indexSize := (int(binary.LittleEndian.Uint32(stream[len(stream)-8:])) + 1) * 4
+	corruptCheck := bytes.Clone(stream)
+	corruptCheck[len(stream)-12-indexSize-1] ^= 0xff
+	corruptFooter := bytes.Clone(stream)
+	corruptFooter[len(stream)-1] = 'X'
+	corruptHeader := bytes.Clone(stream)
+	corruptHeader[7] ^= 0x01
+
+	tests := []struct {
+		name    string
+		in      []byte
+		maxSize int64
+		want    []byte
+		wantErr error
+	}{
+		{"crc64", readTestdata(t, "text.bin.xz"), 0, text, nil},
+		{"crc32", stream, 0, text, nil},
+		{"sha256", readTestdata(t, "text.bin.sha256.xz"), 0, text, nil},
+		{"multiple blocks without check", readTestdata(t, "text.bin.blocks.xz"), 0, text, nil},
+		{"concatenated streams", readTestdata(t, "text.bin.concat.xz"), 0, append(bytes.Clone(text), text...), nil},
+		{"stream padding", append(bytes.Clone(stream), 0, 0, 0, 0), 0, text, nil},
+		{"x86 filter", readTestdata(t, "x86.bin.bcj.xz"), 0, x86, nil},
+		{"delta filter", readTestdata(t, "x86.bin.delta.xz"), 0, x86, nil},
+		{"within limit", stream, int64(len(text)), text, nil},
+		{"over limit", stream, int64(len(text)) - 1, nil, kaitai.ErrMaxSizeExceeded},
+		{"check mismatch", corruptCheck, 0, nil, ErrCorrupt},
+		{"invalid footer", corruptFooter, 0, nil, ErrCorrupt},
+		{"header checksum mismatch", corruptHeader, 0, nil, ErrCorrupt},
+		{"invalid stream padding", append(bytes.Clone(stream), 0, 0, 0), 0, nil, ErrCorrupt},
+		{"truncated", stream[:len(stream)-20], 0, nil, ErrCorrupt},
+		{"unknown magic", []byte("not an xz file"), 0, nil, ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := XZ(tt.in, tt.maxSize)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("XZ() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("XZ() returned %d bytes, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func Test_xzVarint(t *testing.T) {
+	tests := []struct {
+		in    []byte
+		want  uint64
+		wantN int
+	}{
+		{[]byte{0x00}, 0, 1},
+		{[]byte{0x7f, 0xff}, 0x7f, 1},
+		{[]byte{0x80, 0x01}, 0x80, 2},
+		{[]byte{0xff, 0xff, 0x03}, 0xffff, 3},
+		{[]byte{0x80, 0x00}, 0, 0},
+		{[]byte{0x80}, 0, 0},
+		{bytes.Repeat([]byte{0x80}, 10), 0, 0},
+	}
+	for _, tt := range tests {
+		got, n := xzVarint(tt.in)
+		if got != tt.want || n != tt.wantN {
+			t.Errorf("xzVarint(%x) = %v, %v, want %v, %v", tt.in, got, n, tt.want, tt.wantN)
+		}
+	}
+}
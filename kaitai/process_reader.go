@@ -0,0 +1,212 @@
+package kaitai
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+)
+
+// ErrMaxSizeExceeded is returned when processed data grows beyond the
+// maximum size given by the caller, e.g. when decompressing a decompression
+// bomb.
+var ErrMaxSizeExceeded = errors.New("maximum output size exceeded")
+
+// NewZlibReader returns a reader decompressing data read from r as specified
+// in RFC 1950. It is the streaming counterpart of ProcessZlib. The returned
+// reader must be closed once it's no longer used, which makes its state
+// available for reuse.
+func NewZlibReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := newZlibReader(r)
+	if err != nil {
+		if zr != nil {
+			zlibReaders.Put(zr)
+		}
+		return nil, fmt.Errorf("NewZlibReader: error initializing zlib reader: %w", err)
+	}
+	return &pooledZlibReader{zr}, nil
+}
+
+// pooledZlibReader returns its zlib reader to the pool when closed, after
+// which it fails reading with os.ErrClosed.
+type pooledZlibReader struct {
+	io.ReadCloser
+}
+
+func (z *pooledZlibReader) Read(p []byte) (int, error) {
+	if z.ReadCloser == nil {
+		return 0, fmt.Errorf("ZlibReader: %w", os.ErrClosed)
+	}
+	return z.ReadCloser.Read(p)
+}
+
+func (z *pooledZlibReader) Close() error {
+	if z.ReadCloser == nil {
+		return nil
+	}
+	err := z.ReadCloser.Close()
+	zlibReaders.Put(z.ReadCloser)
+	z.ReadCloser = nil
+	return err
+}
+
+// NewXORReader returns a reader which xors data read from r with the key. It
+// is the streaming counterpart of ProcessXOR: the position within the key
+// carries over from one Read call to the next. Reading fails with ErrEmptyKey
+// if the key is empty.
+func NewXORReader(r io.Reader, key []byte) io.Reader {
+	return &xorReader{r: r, key: key}
+}
+
+type xorReader struct {
+	r   io.Reader
+	key []byte
+	// Index into key for the next byte read
+	phase int
+}
+
+func (x *xorReader) Read(p []byte) (int, error) {
+	if len(x.key) == 0 {
+		return 0, fmt.Errorf("XORReader: %w", ErrEmptyKey)
+	}
+	n, err := x.r.Read(p)
+	x.phase = xorKey(p[:n], p[:n], x.key, x.phase)
+	return n, err
+}
+
+// NewRotateLeftReader returns a reader which rotates the single bytes read
+// from r left by amount bits. It is the streaming counterpart of
+// ProcessRotateLeft.
+func NewRotateLeftReader(r io.Reader, amount int) io.Reader {
+	return &rotateReader{r, amount}
+}
+
+// NewRotateRightReader returns a reader which rotates the single bytes read
+// from r right by amount bits. It is the streaming counterpart of
+// ProcessRotateRight.
+func NewRotateRightReader(r io.Reader, amount int) io.Reader {
+	return &rotateReader{r, -amount}
+}
+
+type rotateReader struct {
+	r      io.Reader
+	amount int
+}
+
+func (rr *rotateReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	for i := 0; i < n; i++ {
+		p[i] = bits.RotateLeft8(p[i], rr.amount)
+	}
+	return n, err
+}
+
+// maxSizeReader reads from r, failing with ErrMaxSizeExceeded as soon as r
+// turns out to hold more than n bytes. Unlike io.LimitedReader, it doesn't
+// silently truncate the data.
+type maxSizeReader struct {
+	r io.Reader
+	// Number of bytes which may still be read
+	n int64
+}
+
+func (m *maxSizeReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if m.n <= 0 {
+		var probe [1]byte
+		n, err := m.r.Read(probe[:])
+		if n > 0 {
+			return 0, ErrMaxSizeExceeded
+		}
+		return 0, err
+	}
+	if int64(len(p)) > m.n {
+		p = p[:m.n]
+	}
+	n, err := m.r.Read(p)
+	m.n -= int64(n)
+	return n, err
+}
+
+// limitReader applies the maximum size to r, unless it is not positive.
+func limitReader(r io.Reader, maxSize int64) io.Reader {
+	if maxSize <= 0 {
+		return r
+	}
+	return &maxSizeReader{r, maxSize}
+}
+
+// NewSpooledStream reads all data from r, typically a reader decoding a
+// process such as the one returned by NewZlibReader, and returns a Stream
+// providing random access to it. Up to memLimit bytes, which must not be
+// negative, are kept in memory; larger data is spilled to a temporary file,
+// which is removed when the Stream is closed. If maxSize is positive and r
+// yields more than maxSize bytes, reading stops with ErrMaxSizeExceeded.
+//
+// Only the decoded data is held, so a large payload can be processed without
+// materializing its encoded form as well:
+//
+//	zr, err := kaitai.NewZlibReader(substream)
+//	...
+//	defer zr.Close()
+//	s, err := kaitai.NewSpooledStream(zr, 1<<20, 1<<32)
+//	...
+//	defer s.Close()
+func NewSpooledStream(r io.Reader, memLimit, maxSize int64) (*Stream, error) {
+	if memLimit < 0 {
+		return nil, fmt.Errorf("NewSpooledStream: negative memory limit %d: %w", memLimit, ErrInvalidSizeRequested)
+	}
+	r = limitReader(r, maxSize)
+
+	var mem bytes.Buffer
+	if _, err := io.CopyN(&mem, r, memLimit); err == io.EOF {
+		return NewStream(bytes.NewReader(mem.Bytes())), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("NewSpooledStream: error reading data: %w", err)
+	}
+	// All of memLimit is used, so the data only fits in memory if nothing
+	// follows
+	var probe [1]byte
+	if _, err := io.ReadFull(r, probe[:]); err == io.EOF {
+		return NewStream(bytes.NewReader(mem.Bytes())), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("NewSpooledStream: error reading data: %w", err)
+	}
+	mem.WriteByte(probe[0])
+
+	f, err := os.CreateTemp("", "kaitai-spool-*")
+	if err != nil {
+		return nil, fmt.Errorf("NewSpooledStream: error creating temporary file: %w", err)
+	}
+	tf := &tempFile{f}
+	if _, err := mem.WriteTo(f); err != nil {
+		tf.Close()
+		return nil, fmt.Errorf("NewSpooledStream: error writing temporary file: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		tf.Close()
+		return nil, fmt.Errorf("NewSpooledStream: error spooling data: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		tf.Close()
+		return nil, fmt.Errorf("NewSpooledStream: error seeking temporary file: %w", err)
+	}
+	return NewStream(tf), nil
+}
+
+// tempFile is a file which is removed when closed.
+type tempFile struct {
+	*os.File
+}
+
+func (t *tempFile) Close() error {
+	err := t.File.Close()
+	if rerr := os.Remove(t.Name()); rerr != nil && err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package kaitai
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNewZlibReader(t *testing.T) {
+	want := bytes.Repeat([]byte("goodbye, world "), 100)
+	in, err := UnprocessZlib(want, zlib.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		r, err := NewZlibReader(iotest.HalfReader(bytes.NewReader(in)))
+		if err != nil {
+			t.Fatalf("NewZlibReader() error = %v", err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("reading zlib data: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("NewZlibReader() read %q, want %q", got, want)
+		}
+		if err := r.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+		if err := r.Close(); err != nil {
+			t.Errorf("second Close() error = %v", err)
+		}
+		if _, err := r.Read(make([]byte, 1)); !errors.Is(err, os.ErrClosed) {
+			t.Errorf("Read() after Close() error = %v, want %v", err, os.ErrClosed)
+		}
+	}
+
+	if _, err := NewZlibReader(bytes.NewReader([]byte{0x00})); err == nil {
+		t.Error("NewZlibReader() error = nil, want error")
+	}
+}
+
+func TestNewXORReader(t *testing.T) {
+	data := bytes.Repeat([]byte("some data to xor with a multi-byte key"), 10)
+	key := []byte{0x01, 0x02, 0x03}
+	want := ProcessXOR(data, key)
+	tests := []struct {
+		name string
+		r    io.Reader
+	}{
+		// OneByteReader makes every Read call return a single byte, so the
+		// key phase has to carry over between calls
+		{"one byte", iotest.OneByteReader(bytes.NewReader(data))},
+		{"half", iotest.HalfReader(bytes.NewReader(data))},
+		{"whole", bytes.NewReader(data)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(NewXORReader(tt.r, key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("NewXORReader() read %v, want %v", got, want)
+			}
+		})
+	}
+
+	if _, err := io.ReadAll(NewXORReader(bytes.NewReader(data), nil)); !errors.Is(err, ErrEmptyKey) {
+		t.Errorf("NewXORReader() with empty key error = %v, want %v", err, ErrEmptyKey)
+	}
+}
+
+func TestNewRotateReader(t *testing.T) {
+	data := []byte{0x01, 0x80, 0x5a, 0xff}
+	tests := []struct {
+		name string
+		r    io.Reader
+		want []byte
+	}{
+		{"left", NewRotateLeftReader(bytes.NewReader(data), 3), ProcessRotateLeft(data, 3)},
+		{"right", NewRotateRightReader(bytes.NewReader(data), 3), ProcessRotateRight(data, 3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(tt.r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("read %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSpooledStream(t *testing.T) {
+	data := bytes.Repeat([]byte{1, 2, 3, 4}, 256)
+	tests := []struct {
+		name     string
+		memLimit int64
+		maxSize  int64
+		wantFile bool
+		wantErr  error
+	}{
+		{"in memory", 4096, 0, false, nil},
+		{"no memory limit", math.MaxInt64, 0, false, nil},
+		{"no memory", 0, 0, true, nil},
+		{"negative memory limit", -1, 0, false, ErrInvalidSizeRequested},
+		{"exactly at memory limit", 1024, 1024, false, nil},
+		{"spilled", 100, 0, true, nil},
+		{"too large in memory", 4096, 1023, false, ErrMaxSizeExceeded},
+		{"too large spilled", 100, 1000, true, ErrMaxSizeExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSpooledStream(iotest.HalfReader(bytes.NewReader(data)), tt.memLimit, tt.maxSize)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewSpooledStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			tf, isFile := s.ReadSeeker.(*tempFile)
+			if isFile != tt.wantFile {
+				t.Errorf("NewSpooledStream() spilled to file = %v, want %v", isFile, tt.wantFile)
+			}
+
+			if _, err := s.Seek(512, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.ReadBytesFull()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data[512:]) {
+				t.Errorf("Stream.ReadBytesFull() = %v, want %v", got, data[512:])
+			}
+
+			if err := s.Close(); err != nil {
+				t.Errorf("Stream.Close() error = %v", err)
+			}
+			if isFile {
+				if _, err := os.Stat(tf.Name()); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("temporary file %s still exists after Close: %v", tf.Name(), err)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSpooledStream_zlibBomb(t *testing.T) {
+	in, err := UnprocessZlib(make([]byte, 1<<20), zlib.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := NewZlibReader(bytes.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	if _, err := NewSpooledStream(zr, 1<<10, 1<<16); !errors.Is(err, ErrMaxSizeExceeded) {
+		t.Errorf("NewSpooledStream() error = %v, want %v", err, ErrMaxSizeExceeded)
+	}
+}
@@ -0,0 +1,264 @@
+package kaitai
+
+import (
+	"bytes"
+	"encoding/ascii85"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ProcessHex decodes pairs of hexadecimal digits, in either case, into bytes.
+func ProcessHex(in []byte) ([]byte, error) {
+	out := make([]byte, len(in)/2)
+	if _, err := hex.Decode(out, in); err != nil {
+		// hex.Decode doesn't report where the problem is
+		offset := int64(len(in))
+		for i, c := range in {
+			if _, ok := fromHexChar(c); !ok {
+				offset = int64(i)
+				break
+			}
+		}
+		return nil, fmt.Errorf("ProcessHex: %w", TextDecodeError{"hex", offset, err})
+	}
+	return out, nil
+}
+
+func fromHexChar(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// UnprocessHex encodes data as lowercase hexadecimal digits.
+func UnprocessHex(data []byte) []byte {
+	out := make([]byte, hex.EncodedLen(len(data)))
+	hex.Encode(out, data)
+	return out
+}
+
+// ProcessBase64 decodes base64 text as specified in RFC 4648, using one of
+// the encodings of package encoding/base64: StdEncoding or URLEncoding for
+// padded data, RawStdEncoding or RawURLEncoding for unpadded data. Line breaks
+// are ignored.
+func ProcessBase64(in []byte, enc *base64.Encoding) ([]byte, error) {
+	out := make([]byte, enc.DecodedLen(len(in)))
+	n, err := enc.Decode(out, in)
+	if err != nil {
+		return nil, fmt.Errorf("ProcessBase64: %w", newTextDecodeError("base64", err))
+	}
+	return out[:n], nil
+}
+
+// UnprocessBase64 encodes data as base64 text using enc, the inverse of
+// ProcessBase64.
+func UnprocessBase64(data []byte, enc *base64.Encoding) []byte {
+	out := make([]byte, enc.EncodedLen(len(data)))
+	enc.Encode(out, data)
+	return out
+}
+
+// ProcessBase32 decodes base32 text as specified in RFC 4648, using one of
+// the encodings of package encoding/base32, such as StdEncoding, HexEncoding
+// or their unpadded variants. Line breaks are ignored.
+func ProcessBase32(in []byte, enc *base32.Encoding) ([]byte, error) {
+	out := make([]byte, enc.DecodedLen(len(in)))
+	n, err := enc.Decode(out, in)
+	if err != nil {
+		return nil, fmt.Errorf("ProcessBase32: %w", newTextDecodeError("base32", err))
+	}
+	return out[:n], nil
+}
+
+// UnprocessBase32 encodes data as base32 text using enc, the inverse of
+// ProcessBase32.
+func UnprocessBase32(data []byte, enc *base32.Encoding) []byte {
+	out := make([]byte, enc.EncodedLen(len(data)))
+	enc.Encode(out, data)
+	return out
+}
+
+var (
+	ascii85Prefix = []byte("<~")
+	ascii85Suffix = []byte("~>")
+)
+
+// asciiSpace is the whitespace ignored by ascii85.Decode.
+const asciiSpace = " \t\n\v\f\r"
+
+// ProcessASCII85 decodes ascii85 text, as used by PostScript and PDF. The
+// data may be enclosed in the "<~" and "~>" delimiters. Whitespace is
+// ignored, including before and after the delimiters.
+func ProcessASCII85(in []byte) ([]byte, error) {
+	trimmed := bytes.TrimLeft(in, asciiSpace)
+	offset := int64(len(in) - len(trimmed))
+	in = trimmed
+	if bytes.HasPrefix(in, ascii85Prefix) {
+		in = in[len(ascii85Prefix):]
+		offset += int64(len(ascii85Prefix))
+	}
+	in = bytes.TrimSuffix(bytes.TrimRight(in, asciiSpace), ascii85Suffix)
+
+	// Each "z" stands for 4 zero bytes
+	out := make([]byte, 4*len(in)+4)
+	n, _, err := ascii85.Decode(out, in, true)
+	if err != nil {
+		e := newTextDecodeError("ascii85", err)
+		e.Offset += offset
+		return nil, fmt.Errorf("ProcessASCII85: %w", e)
+	}
+	return out[:n], nil
+}
+
+// UnprocessASCII85 encodes data as ascii85 text without delimiters, the
+// inverse of ProcessASCII85.
+func UnprocessASCII85(data []byte) []byte {
+	out := make([]byte, ascii85.MaxEncodedLen(len(data)))
+	n := ascii85.Encode(out, data)
+	return out[:n]
+}
+
+// newTextDecodeError converts the errors of the standard library decoders,
+// which carry the offset of the invalid input, into a TextDecodeError.
+func newTextDecodeError(encoding string, err error) TextDecodeError {
+	var (
+		b64 base64.CorruptInputError
+		b32 base32.CorruptInputError
+		a85 ascii85.CorruptInputError
+	)
+	e := TextDecodeError{Encoding: encoding, Err: err}
+	switch {
+	case errors.As(err, &b64):
+		e.Offset = int64(b64)
+	case errors.As(err, &b32):
+		e.Offset = int64(b32)
+	case errors.As(err, &a85):
+		e.Offset = int64(a85)
+	}
+	return e
+}
+
+var (
+	uuBegin = []byte("begin ")
+	uuEnd   = []byte("end")
+)
+
+// uuLineLen is the number of bytes encoded on each full line by
+// UnprocessUuencode, the customary maximum.
+const uuLineLen = 45
+
+// ProcessUuencode decodes uuencoded data. The input may be a complete
+// uuencoded file, starting with a "begin" line and finishing with an "end"
+// line, or just the encoded lines. Decoding stops at the first line encoding
+// zero bytes.
+func ProcessUuencode(in []byte) ([]byte, error) {
+	var out []byte
+	pos := 0
+	first := true
+	for pos < len(in) {
+		lineStart := pos
+		line := in[pos:]
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+			pos += i + 1
+		} else {
+			pos = len(in)
+		}
+		line = bytes.TrimSuffix(line, []byte{'\r'})
+		if first && bytes.HasPrefix(line, uuBegin) {
+			first = false
+			continue
+		}
+		first = false
+		if len(line) == 0 {
+			continue
+		}
+
+		n, err := uuDecodeChar(line[0], lineStart)
+		if err != nil {
+			return nil, fmt.Errorf("ProcessUuencode: %w", err)
+		}
+		if n == 0 {
+			break
+		}
+		chars := (int(n) + 2) / 3 * 4
+		if len(line)-1 < chars {
+			return nil, fmt.Errorf("ProcessUuencode: %w", TextDecodeError{"uuencode", int64(lineStart + len(line)), errors.New("line too short")})
+		}
+		var group [4]byte
+		for i := 0; i < int(n); i += 3 {
+			for j := range group {
+				c := 1 + i/3*4 + j
+				if group[j], err = uuDecodeChar(line[c], lineStart+c); err != nil {
+					return nil, fmt.Errorf("ProcessUuencode: %w", err)
+				}
+			}
+			decoded := [3]byte{
+				group[0]<<2 | group[1]>>4,
+				group[1]<<4 | group[2]>>2,
+				group[2]<<6 | group[3],
+			}
+			out = append(out, decoded[:min(3, int(n)-i)]...)
+		}
+	}
+	if out == nil {
+		out = []byte{}
+	}
+	return out, nil
+}
+
+// uuDecodeChar returns the 6-bit value of the uuencoded character c found at
+// offset.
+func uuDecodeChar(c byte, offset int) (byte, error) {
+	if c < ' ' || c > '`' {
+		return 0, TextDecodeError{"uuencode", int64(offset), fmt.Errorf("illegal character %q", c)}
+	}
+	return (c - ' ') & 0x3f, nil
+}
+
+// UnprocessUuencode uuencodes data, the inverse of ProcessUuencode. If
+// header is not empty, the encoded lines are enclosed in a "begin" line
+// followed by header, typically a file mode and name such as "644 data.bin",
+// and an "end" line.
+func UnprocessUuencode(data []byte, header string) []byte {
+	var out []byte
+	if header != "" {
+		out = append(append(append(out, uuBegin...), header...), '\n')
+	}
+	enc := func(b byte) byte {
+		if b == 0 {
+			return '`'
+		}
+		return ' ' + b
+	}
+	for len(data) > 0 {
+		n := min(len(data), uuLineLen)
+		out = append(out, enc(byte(n)))
+		for i := 0; i < n; i += 3 {
+			var group [3]byte
+			copy(group[:], data[i:n])
+			out = append(out,
+				enc(group[0]>>2),
+				enc((group[0]<<4|group[1]>>4)&0x3f),
+				enc((group[1]<<2|group[2]>>6)&0x3f),
+				enc(group[2]&0x3f),
+			)
+		}
+		out = append(out, '\n')
+		data = data[n:]
+	}
+	out = append(out, '`', '\n')
+	if header != "" {
+		out = append(append(out, uuEnd...), '\n')
+	}
+	return out
+}
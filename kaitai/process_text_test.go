@@ -0,0 +1,147 @@
+package kaitai
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestProcessText(t *testing.T) {
+	type decodeFunc func([]byte) ([]byte, error)
+	type encodeFunc func([]byte) []byte
+	b64 := func(enc *base64.Encoding) (decodeFunc, encodeFunc) {
+		return func(in []byte) ([]byte, error) { return ProcessBase64(in, enc) },
+			func(data []byte) []byte { return UnprocessBase64(data, enc) }
+	}
+	b32 := func(enc *base32.Encoding) (decodeFunc, encodeFunc) {
+		return func(in []byte) ([]byte, error) { return ProcessBase32(in, enc) },
+			func(data []byte) []byte { return UnprocessBase32(data, enc) }
+	}
+	uu := func(header string) (decodeFunc, encodeFunc) {
+		return ProcessUuencode, func(data []byte) []byte { return UnprocessUuencode(data, header) }
+	}
+	codec := func(d decodeFunc, e encodeFunc) struct {
+		decode decodeFunc
+		encode encodeFunc
+	} {
+		return struct {
+			decode decodeFunc
+			encode encodeFunc
+		}{d, e}
+	}
+
+	tests := []struct {
+		name  string
+		codec struct {
+			decode decodeFunc
+			encode encodeFunc
+		}
+		in   string
+		want []byte
+	}{
+		{"hex", codec(ProcessHex, UnprocessHex), "00ff7f", []byte{0x00, 0xff, 0x7f}},
+		{"base64 std", codec(b64(base64.StdEncoding)), "+/8=", []byte{0xfb, 0xff}},
+		{"base64 url", codec(b64(base64.URLEncoding)), "-_8=", []byte{0xfb, 0xff}},
+		{"base64 raw std", codec(b64(base64.RawStdEncoding)), "+/8", []byte{0xfb, 0xff}},
+		{"base64 raw url", codec(b64(base64.RawURLEncoding)), "-_8", []byte{0xfb, 0xff}},
+		{"base32", codec(b32(base32.StdEncoding)), "MZXW6YTBOI======", []byte("foobar")},
+		{"base32 hex", codec(b32(base32.HexEncoding)), "CPNMUOJ1E8======", []byte("foobar")},
+		{"ascii85", codec(ProcessASCII85, UnprocessASCII85), "87cURD]i,\"Ebo80", []byte("Hello World!")},
+		{"ascii85 zero group", codec(ProcessASCII85, UnprocessASCII85), "z", []byte{0, 0, 0, 0}},
+		{"uuencode lines", codec(uu("")), "#0V%T\n`\n", []byte("Cat")},
+		{
+			"uuencode file", codec(uu("644 cat.txt")), "begin 644 cat.txt\n.:&5L;&\\L('=O<FQD`/\\`\n`\nend\n",
+			[]byte("hello, world\x00\xff"),
+		},
+		{"uuencode empty", codec(uu("")), "`\n", []byte{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.codec.decode([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("decode() = %q, want %q", got, tt.want)
+			}
+			if enc := tt.codec.encode(tt.want); string(enc) != tt.in {
+				t.Errorf("encode() = %q, want %q", enc, tt.in)
+			}
+		})
+	}
+}
+
+func TestProcessText_lenient(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func([]byte) ([]byte, error)
+		in     string
+		want   []byte
+	}{
+		{"hex uppercase", ProcessHex, "ABCDEF", []byte{0xab, 0xcd, 0xef}},
+		{"base64 line breaks", func(in []byte) ([]byte, error) { return ProcessBase64(in, base64.StdEncoding) }, "aGVs\r\nbG8=\n", []byte("hello")},
+		{"ascii85 delimiters", ProcessASCII85, "<~87cURD]i,\"Ebo80~>", []byte("Hello World!")},
+		{"ascii85 whitespace", ProcessASCII85, "87cUR D]i,\n\"Ebo80", []byte("Hello World!")},
+		{"ascii85 delimiters and newlines", ProcessASCII85, "\n<~87cURD]i,\"Ebo80~>\r\n", []byte("Hello World!")},
+		{"uuencode spaces for zero", ProcessUuencode, "#0V%T\n \n", []byte("Cat")},
+		{"uuencode without terminator", ProcessUuencode, "#0V%T\r\n", []byte("Cat")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.decode([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("decode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessText_invalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		decode     func([]byte) ([]byte, error)
+		in         string
+		wantOffset int64
+	}{
+		{"hex invalid char", ProcessHex, "00g0", 2},
+		{"hex odd length", ProcessHex, "00f", 3},
+		{"base64 invalid char", func(in []byte) ([]byte, error) { return ProcessBase64(in, base64.StdEncoding) }, "aGV*", 3},
+		{"base64 missing padding", func(in []byte) ([]byte, error) { return ProcessBase64(in, base64.StdEncoding) }, "aGVsbA", 4},
+		{"base64 url char in std", func(in []byte) ([]byte, error) { return ProcessBase64(in, base64.StdEncoding) }, "-_8=", 0},
+		{"base32 invalid char", func(in []byte) ([]byte, error) { return ProcessBase32(in, base32.StdEncoding) }, "MZXW1YTB", 4},
+		{"ascii85 invalid char", ProcessASCII85, "87cU{", 4},
+		{"ascii85 invalid char after delimiter", ProcessASCII85, "<~87cU{~>", 6},
+		{"ascii85 invalid char after space and delimiter", ProcessASCII85, " <~87cU{~>\n", 7},
+		{"uuencode invalid char", ProcessUuencode, "#0V%T\n#0V~T\n", 9},
+		{"uuencode invalid length", ProcessUuencode, "begin 644 x\n~abc\n", 12},
+		{"uuencode short line", ProcessUuencode, "#0V%T\n#0V\n", 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.decode([]byte(tt.in))
+			var e TextDecodeError
+			if !errors.As(err, &e) {
+				t.Fatalf("decode() error = %v, want TextDecodeError", err)
+			}
+			if e.Offset != tt.wantOffset {
+				t.Errorf("TextDecodeError.Offset = %v, want %v (%v)", e.Offset, tt.wantOffset, err)
+			}
+		})
+	}
+}
+
+func TestUnprocessUuencode_longData(t *testing.T) {
+	data := bytes.Repeat([]byte{0, 1, 2, 3, 0xfe, 0xff, 'x'}, 50)
+	got, err := ProcessUuencode(UnprocessUuencode(data, "600 f"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("ProcessUuencode(UnprocessUuencode()) = %v, want %v", got, data)
+	}
+}
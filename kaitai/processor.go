@@ -0,0 +1,125 @@
+package kaitai
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownProcessor is returned when no Processor is registered under the
+// requested name.
+var ErrUnknownProcessor = errors.New("unknown processor")
+
+// ErrProcessorNotEncodable is returned when data has to be encoded with a
+// Processor that doesn't implement EncodingProcessor.
+var ErrProcessorNotEncodable = errors.New("processor does not support encoding")
+
+// ErrNilProcessor is returned when the factory of a Processor returns
+// neither a Processor nor an error.
+var ErrNilProcessor = errors.New("processor factory returned nil")
+
+// Processor is implemented by custom process routines, which are declared in
+// specs as `process: name(args)`.
+type Processor interface {
+	// Decode returns data with the process applied, as done when parsing.
+	Decode(data []byte) ([]byte, error)
+}
+
+// EncodingProcessor is implemented by Processors which can also reverse the
+// process, as needed for serialization.
+type EncodingProcessor interface {
+	Processor
+	// Encode returns data with the process reversed, such that Decode
+	// returns the original data.
+	Encode(data []byte) ([]byte, error)
+}
+
+// ProcessorFactory creates a Processor from the arguments given to the
+// process in the spec.
+type ProcessorFactory func(args ...interface{}) (Processor, error)
+
+var (
+	processorsMu sync.RWMutex
+	processors   = make(map[string]ProcessorFactory)
+)
+
+// RegisterProcessor makes a Processor available under the given name. It is
+// intended to be called from init functions and panics if factory is nil or
+// if a Processor is already registered under name.
+func RegisterProcessor(name string, factory ProcessorFactory) {
+	processorsMu.Lock()
+	defer processorsMu.Unlock()
+	if factory == nil {
+		panic("kaitai: RegisterProcessor factory is nil")
+	}
+	if _, dup := processors[name]; dup {
+		panic("kaitai: RegisterProcessor called twice for processor " + name)
+	}
+	processors[name] = factory
+}
+
+// Processors returns the sorted names of all registered Processors.
+func Processors() []string {
+	processorsMu.RLock()
+	defer processorsMu.RUnlock()
+	names := make([]string, 0, len(processors))
+	for name := range processors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProcessor creates the Processor registered under name with the given
+// arguments.
+func NewProcessor(name string, args ...interface{}) (Processor, error) {
+	processorsMu.RLock()
+	factory, ok := processors[name]
+	processorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("NewProcessor(%q): %w", name, ErrUnknownProcessor)
+	}
+	p, err := factory(args...)
+	if err != nil {
+		return nil, fmt.Errorf("NewProcessor(%q): %w", name, err)
+	}
+	if p == nil {
+		return nil, fmt.Errorf("NewProcessor(%q): %w", name, ErrNilProcessor)
+	}
+	return p, nil
+}
+
+// Process decodes data, the raw bytes of the field at srcPath read from io,
+// with the Processor registered under name. Any error is returned as a
+// ProcessError.
+func Process(name string, args []interface{}, data []byte, io *Stream, srcPath string) ([]byte, error) {
+	p, err := NewProcessor(name, args...)
+	if err != nil {
+		return nil, NewProcessError(name, err, io, srcPath)
+	}
+	res, err := p.Decode(data)
+	if err != nil {
+		return nil, NewProcessError(name, err, io, srcPath)
+	}
+	return res, nil
+}
+
+// Unprocess encodes data of the field at srcPath with the Processor
+// registered under name, which must implement EncodingProcessor. Any error is
+// returned as a ProcessError without a stream.
+func Unprocess(name string, args []interface{}, data []byte, srcPath string) ([]byte, error) {
+	p, err := NewProcessor(name, args...)
+	if err != nil {
+		return nil, NewProcessError(name, err, nil, srcPath)
+	}
+	ep, ok := p.(EncodingProcessor)
+	if !ok {
+		return nil, NewProcessError(name, ErrProcessorNotEncodable, nil, srcPath)
+	}
+	res, err := ep.Encode(data)
+	if err != nil {
+		return nil, NewProcessError(name, err, nil, srcPath)
+	}
+	return res, nil
+}
@@ -0,0 +1,143 @@
+package kaitai
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// addProcessor adds a constant to every byte, mimicking a custom process
+// declared as `process: test_add(n)`.
+type addProcessor struct {
+	n byte
+}
+
+func (p addProcessor) Decode(data []byte) ([]byte, error) {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b + p.n
+	}
+	return out, nil
+}
+
+func (p addProcessor) Encode(data []byte) ([]byte, error) {
+	return addProcessor{-p.n}.Decode(data)
+}
+
+// failingProcessor supports decoding only and always fails.
+type failingProcessor struct{}
+
+func (failingProcessor) Decode([]byte) ([]byte, error) {
+	return nil, errTestProcess
+}
+
+var errTestProcess = errors.New("test process failure")
+
+func init() {
+	RegisterProcessor("test_add", func(args ...interface{}) (Processor, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		n, ok := args[0].(int)
+		if !ok {
+			return nil, fmt.Errorf("expected int argument, got %T", args[0])
+		}
+		return addProcessor{byte(n)}, nil
+	})
+	RegisterProcessor("test_fail", func(...interface{}) (Processor, error) {
+		return failingProcessor{}, nil
+	})
+	RegisterProcessor("test_nil", func(...interface{}) (Processor, error) {
+		return nil, nil
+	})
+}
+
+func TestRegisterProcessor_duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterProcessor() did not panic for a duplicate name")
+		}
+	}()
+	RegisterProcessor("test_add", func(...interface{}) (Processor, error) { return nil, nil })
+}
+
+func TestProcessors(t *testing.T) {
+	got := Processors()
+	for _, name := range []string{"test_add", "test_fail"} {
+		found := false
+		for _, n := range got {
+			found = found || n == name
+		}
+		if !found {
+			t.Errorf("Processors() = %v, missing %q", got, name)
+		}
+	}
+}
+
+func TestProcess(t *testing.T) {
+	io := NewStream(bytes.NewReader([]byte("test")))
+	tests := []struct {
+		name    string
+		proc    string
+		args    []interface{}
+		want    []byte
+		wantErr error
+	}{
+		{"registered", "test_add", []interface{}{1}, []byte{2, 3, 4}, nil},
+		{"bad arguments", "test_add", []interface{}{"x"}, nil, nil},
+		{"unknown", "test_unknown", nil, nil, ErrUnknownProcessor},
+		{"decode failure", "test_fail", nil, nil, errTestProcess},
+		{"nil processor", "test_nil", nil, nil, ErrNilProcessor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Process(tt.proc, tt.args, []byte{1, 2, 3}, io, "/seq/0")
+			if tt.want != nil {
+				if err != nil {
+					t.Fatalf("Process() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Process() = %v, want %v", got, tt.want)
+				}
+				return
+			}
+			var procErr ProcessError
+			if !errors.As(err, &procErr) {
+				t.Fatalf("Process() error = %v, want ProcessError", err)
+			}
+			if procErr.Name() != tt.proc || procErr.SrcPath() != "/seq/0" || procErr.Io() != io {
+				t.Errorf("Process() error = %#v, want location of %s at /seq/0", procErr, tt.proc)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Process() error = %v, want it to wrap %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnprocess(t *testing.T) {
+	got, err := Unprocess("test_add", []interface{}{1}, []byte{2, 3, 4}, "/seq/0")
+	if err != nil {
+		t.Fatalf("Unprocess() error = %v", err)
+	}
+	if want := []byte{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unprocess() = %v, want %v", got, want)
+	}
+
+	_, err = Unprocess("test_fail", nil, []byte{1}, "/seq/1")
+	if !errors.Is(err, ErrProcessorNotEncodable) {
+		t.Errorf("Unprocess() error = %v, want %v", err, ErrProcessorNotEncodable)
+	}
+	want := "/seq/1: at pos N/A: process test_fail failed: processor does not support encoding"
+	if err.Error() != want {
+		t.Errorf("Unprocess() error = %q, want %q", err.Error(), want)
+	}
+
+	_, err = Unprocess("test_nil", nil, []byte{1}, "/seq/2")
+	var procErr ProcessError
+	if !errors.As(err, &procErr) || !errors.Is(err, ErrNilProcessor) {
+		t.Errorf("Unprocess() error = %v, want ProcessError wrapping %v", err, ErrNilProcessor)
+	}
+}
@@ -0,0 +1,44 @@
+package kaitai
+
+import "runtime/debug"
+
+// SafeParse calls fn, which typically parses a struct from io, and returns
+// its error. If fn panics, e.g. on an index out of range or a nil pointer
+// dereference in an expression, the panic is recovered and returned as a
+// PanicError with the position of io at that time and the stack trace, so a
+// malformed input can't crash the whole program. io may be nil, in which
+// case the position is unknown.
+//
+// A typical call looks like
+//
+//	r := NewFoo()
+//	err := kaitai.SafeParse(io, func() error { return r.Read(io, nil, r) })
+func SafeParse(io *Stream, fn func() error) (err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		err = PanicError{Value: v, Pos: panicPos(io), Stack: debug.Stack()}
+	}()
+	return fn()
+}
+
+// panicPos returns the position of io, or -1 if it's unknown. As the panic
+// may have been caused by the stream itself, e.g. by a Stream without a
+// reader, getting the position may panic as well, which is recovered.
+func panicPos(io *Stream) (pos int64) {
+	if io == nil {
+		return -1
+	}
+	defer func() {
+		if recover() != nil {
+			pos = -1
+		}
+	}()
+	p, err := io.Pos()
+	if err != nil {
+		return -1
+	}
+	return p
+}
@@ -0,0 +1,127 @@
+package kaitai
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// panickingReader panics on every call.
+type panickingReader struct{}
+
+func (panickingReader) Read([]byte) (int, error) { panic("reader failed") }
+
+func (panickingReader) Seek(int64, int) (int64, error) { panic("reader failed") }
+
+func TestSafeParse(t *testing.T) {
+	errParse := errors.New("parse failed")
+	tests := []struct {
+		name    string
+		io      *Stream
+		fn      func(io *Stream) error
+		wantErr error
+		wantPos int64
+		wantMsg string
+	}{
+		{
+			name: "no error",
+			io:   NewStream(bytes.NewReader([]byte{1, 2, 3})),
+			fn: func(io *Stream) error {
+				_, err := io.ReadU1()
+				return err
+			},
+		},
+		{
+			name:    "error",
+			io:      NewStream(bytes.NewReader([]byte{1, 2, 3})),
+			fn:      func(*Stream) error { return errParse },
+			wantErr: errParse,
+		},
+		{
+			name: "index out of range",
+			io:   NewStream(bytes.NewReader([]byte{1, 2, 3})),
+			fn: func(io *Stream) error {
+				b, err := io.ReadBytes(2)
+				if err != nil {
+					return err
+				}
+				i := 3
+				_ = b[i]
+				return nil
+			},
+			wantPos: 2,
+			wantMsg: "panic during parsing at pos 2: runtime error: index out of range [3] with length 2",
+		},
+		{
+			name: "division by zero",
+			io:   NewStream(bytes.NewReader([]byte{0})),
+			fn: func(io *Stream) error {
+				d, err := io.ReadU1()
+				if err != nil {
+					return err
+				}
+				_ = 10 / d
+				return nil
+			},
+			wantPos: 1,
+			wantMsg: "panic during parsing at pos 1: runtime error: integer divide by zero",
+		},
+		{
+			name: "zero Stream",
+			io:   &Stream{},
+			fn: func(io *Stream) error {
+				_, err := io.ReadU1()
+				return err
+			},
+			wantPos: -1,
+			wantMsg: "panic during parsing: runtime error: invalid memory address or nil pointer dereference",
+		},
+		{
+			name: "panicking reader",
+			io:   NewStream(panickingReader{}),
+			fn: func(io *Stream) error {
+				_, err := io.ReadU1()
+				return err
+			},
+			wantPos: -1,
+			wantMsg: "panic during parsing: reader failed",
+		},
+		{
+			name:    "panic with a string and no stream",
+			fn:      func(*Stream) error { panic("boom") },
+			wantPos: -1,
+			wantMsg: "panic during parsing: boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SafeParse(tt.io, func() error { return tt.fn(tt.io) })
+			if tt.wantMsg == "" {
+				if err != tt.wantErr {
+					t.Fatalf("SafeParse() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			var pe PanicError
+			if !errors.As(err, &pe) {
+				t.Fatalf("SafeParse() error = %v (%T), want PanicError", err, err)
+			}
+			if got := pe.Error(); got != tt.wantMsg {
+				t.Errorf("PanicError.Error() = %q, want %q", got, tt.wantMsg)
+			}
+			if pe.Pos != tt.wantPos {
+				t.Errorf("PanicError.Pos = %v, want %v", pe.Pos, tt.wantPos)
+			}
+			if !strings.Contains(string(pe.Stack), "TestSafeParse") {
+				t.Errorf("PanicError.Stack doesn't contain the panicking function:\n%s", pe.Stack)
+			}
+			var re runtime.Error
+			_, isString := pe.Value.(string)
+			if got, want := errors.As(err, &re), !isString; got != want {
+				t.Errorf("errors.As(%v, *runtime.Error) = %v, want %v", err, got, want)
+			}
+		})
+	}
+}
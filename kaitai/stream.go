@@ -30,6 +30,15 @@ type Stream struct {
 	// Number of bits remaining in "bits" for sequential calls to ReadBitsInt
 	bitsLeft int
 	bits     uint64
+
+	// Number of bytes on each side of the position captured by errors
+	errorContext int
+
+	// Collector of validation errors in lenient mode, nil in strict mode
+	validations *ValidationCollector
+
+	// Handler of non-fatal warnings, nil if they are ignored
+	warnings WarningHandler
 }
 
 // NewStream creates and initializes a new Buffer based on r.
@@ -37,6 +46,48 @@ func NewStream(r io.ReadSeeker) *Stream {
 	return &Stream{ReadSeeker: r}
 }
 
+// Close closes the underlying reader if it implements io.Closer.
+func (k *Stream) Close() error {
+	if c, ok := k.ReadSeeker.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// SetErrorContext makes validation errors created for this stream capture up
+// to n bytes before and after the current position, available through their
+// Window method. This requires reading the stream when the error is created,
+// so it is disabled by default; n <= 0 disables it again.
+func (k *Stream) SetErrorContext(n int) {
+	k.errorContext = n
+}
+
+// window returns up to n bytes on each side of pos and the position of the
+// first one. The position of the stream is left unchanged.
+func (k *Stream) window(pos int64, n int) (int64, []byte) {
+	start := pos - int64(n)
+	if start < 0 {
+		start = 0
+	}
+	buf := make([]byte, pos+int64(n)-start)
+	var read int
+	if ra, ok := k.ReadSeeker.(io.ReaderAt); ok {
+		read, _ = ra.ReadAt(buf, start)
+	} else {
+		cur, err := k.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return start, nil
+		}
+		if _, err := k.Seek(start, io.SeekStart); err == nil {
+			read, _ = io.ReadFull(k.ReadSeeker, buf)
+		}
+		if _, err := k.Seek(cur, io.SeekStart); err != nil {
+			return start, nil
+		}
+	}
+	return start, buf[:read]
+}
+
 // EOF returns true when the end of the Stream is reached.
 func (k *Stream) EOF() (bool, error) {
 	if k.bitsLeft > 0 {
@@ -101,58 +152,75 @@ func (k *Stream) Pos() (int64, error) {
 	return pos, nil
 }
 
+// readFull fills p from the stream. Running into the end of the stream is
+// reported as an EndOfStreamError attributed to method.
+func (k *Stream) readFull(method string, p []byte) error {
+	n, err := io.ReadFull(k, p)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%s: error reading %d bytes: %w", method, len(p), err)
+	}
+	pos := int64(-1)
+	if end, perr := k.Pos(); perr == nil {
+		pos = end - int64(n)
+	}
+	return EndOfStreamError{method, pos, len(p), n, err}
+}
+
 // ReadU1 reads 1 byte and returns this as uint8.
 func (k *Stream) ReadU1() (v uint8, err error) {
-	if _, err = io.ReadFull(k, k.buf[:1]); err != nil {
-		return 0, fmt.Errorf("ReadU1: error reading 1 byte: %w", err)
+	if err = k.readFull("ReadU1", k.buf[:1]); err != nil {
+		return 0, err
 	}
 	return k.buf[0], nil
 }
 
 // ReadU2be reads 2 bytes in big-endian order and returns those as uint16.
 func (k *Stream) ReadU2be() (v uint16, err error) {
-	if _, err = io.ReadFull(k, k.buf[:2]); err != nil {
-		return 0, fmt.Errorf("ReadU2be: error reading 2 bytes: %w", err)
+	if err = k.readFull("ReadU2be", k.buf[:2]); err != nil {
+		return 0, err
 	}
 	return binary.BigEndian.Uint16(k.buf[:2]), nil
 }
 
 // ReadU4be reads 4 bytes in big-endian order and returns those as uint32.
 func (k *Stream) ReadU4be() (v uint32, err error) {
-	if _, err = io.ReadFull(k, k.buf[:4]); err != nil {
-		return 0, fmt.Errorf("ReadU4be: error reading 4 bytes: %w", err)
+	if err = k.readFull("ReadU4be", k.buf[:4]); err != nil {
+		return 0, err
 	}
 	return binary.BigEndian.Uint32(k.buf[:4]), nil
 }
 
 // ReadU8be reads 8 bytes in big-endian order and returns those as uint64.
 func (k *Stream) ReadU8be() (v uint64, err error) {
-	if _, err = io.ReadFull(k, k.buf[:8]); err != nil {
-		return 0, fmt.Errorf("ReadU8be: error reading 8 bytes: %w", err)
+	if err = k.readFull("ReadU8be", k.buf[:8]); err != nil {
+		return 0, err
 	}
 	return binary.BigEndian.Uint64(k.buf[:8]), nil
 }
 
 // ReadU2le reads 2 bytes in little-endian order and returns those as uint16.
 func (k *Stream) ReadU2le() (v uint16, err error) {
-	if _, err = io.ReadFull(k, k.buf[:2]); err != nil {
-		return 0, fmt.Errorf("ReadU2le: error reading 2 bytes: %w", err)
+	if err = k.readFull("ReadU2le", k.buf[:2]); err != nil {
+		return 0, err
 	}
 	return binary.LittleEndian.Uint16(k.buf[:2]), nil
 }
 
 // ReadU4le reads 4 bytes in little-endian order and returns those as uint32.
 func (k *Stream) ReadU4le() (v uint32, err error) {
-	if _, err = io.ReadFull(k, k.buf[:4]); err != nil {
-		return 0, fmt.Errorf("ReadU4le: error reading 4 bytes: %w", err)
+	if err = k.readFull("ReadU4le", k.buf[:4]); err != nil {
+		return 0, err
 	}
 	return binary.LittleEndian.Uint32(k.buf[:4]), nil
 }
 
 // ReadU8le reads 8 bytes in little-endian order and returns those as uint64.
 func (k *Stream) ReadU8le() (v uint64, err error) {
-	if _, err = io.ReadFull(k, k.buf[:8]); err != nil {
-		return 0, fmt.Errorf("ReadU8le: error reading 8 bytes: %w", err)
+	if err = k.readFull("ReadU8le", k.buf[:8]); err != nil {
+		return 0, err
 	}
 	return binary.LittleEndian.Uint64(k.buf[:8]), nil
 }
@@ -230,9 +298,8 @@ func (k *Stream) ReadBytes(n int) (b []byte, err error) {
 	}
 
 	b = make([]byte, n)
-	_, err = io.ReadFull(k, b)
-	if err != nil {
-		return nil, fmt.Errorf("ReadBytes: error reading %d bytes: %w", n, err)
+	if err = k.readFull("ReadBytes", b); err != nil {
+		return nil, err
 	}
 	return b, nil
 }
@@ -284,9 +351,12 @@ func (k *Stream) ReadBytesTerm(term byte, includeTerm, consumeTerm, eosError boo
 	if err != nil {
 		// If eosError if false, ignore io.EOF and bail out on any other error
 		// If eosError is true, bail out on any error, including io.EOF
-		if eosError || !errors.Is(err, io.EOF) {
+		if !errors.Is(err, io.EOF) {
 			return slice, fmt.Errorf("ReadBytesTerm: error reading bytes until term byte: %w", err)
 		}
+		if eosError {
+			return slice, EndOfStreamError{"ReadBytesTerm", pos, len(slice) + 1, len(slice), err}
+		}
 	}
 	_, err = k.Seek(pos+int64(len(slice)), io.SeekStart)
 	if err != nil {
@@ -313,6 +383,10 @@ func (k *Stream) ReadBytesTerm(term byte, includeTerm, consumeTerm, eosError boo
 // in this case.
 func (k *Stream) ReadBytesTermMulti(term []byte, includeTerm, consumeTerm, eosError bool) ([]byte, error) {
 	unitSize := len(term)
+	pos, err := k.Pos()
+	if err != nil {
+		return nil, err
+	}
 	r := []byte{}
 	c := make([]byte, unitSize)
 	for {
@@ -320,7 +394,7 @@ func (k *Stream) ReadBytesTermMulti(term []byte, includeTerm, consumeTerm, eosEr
 		if err != nil {
 			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
 				if eosError {
-					return nil, fmt.Errorf("ReadBytesTermMulti: end of stream reached, but no terminator found")
+					return nil, EndOfStreamError{"ReadBytesTermMulti", pos, len(r) + unitSize, len(r) + n, err}
 				}
 				r = append(r, c[:n]...)
 				return r, nil
@@ -365,9 +439,8 @@ func (k *Stream) ReadBitsIntBe(n int) (res uint64, err error) {
 		if bytesNeeded > 8 {
 			return res, fmt.Errorf("ReadBitsIntBe(%d): more than 8 bytes requested: %w", n, ErrInvalidSizeRequested)
 		}
-		_, err = io.ReadFull(k, k.buf[:bytesNeeded])
-		if err != nil {
-			return res, fmt.Errorf("ReadBitsIntBe(%d): %w", n, err)
+		if err = k.readFull("ReadBitsIntBe", k.buf[:bytesNeeded]); err != nil {
+			return res, err
 		}
 		for i := 0; i < bytesNeeded; i++ {
 			res = res<<8 | uint64(k.buf[i])
@@ -406,9 +479,8 @@ func (k *Stream) ReadBitsIntLe(n int) (res uint64, err error) {
 		if bytesNeeded > 8 {
 			return res, fmt.Errorf("ReadBitsIntLe(%d): more than 8 bytes requested: %w", n, ErrInvalidSizeRequested)
 		}
-		_, err = io.ReadFull(k, k.buf[:bytesNeeded])
-		if err != nil {
-			return res, fmt.Errorf("ReadBitsIntLe(%d): %w", n, err)
+		if err = k.readFull("ReadBitsIntLe", k.buf[:bytesNeeded]); err != nil {
+			return res, err
 		}
 		for i := 0; i < bytesNeeded; i++ {
 			res |= uint64(k.buf[i]) << (i * 8)
@@ -2,6 +2,7 @@ package kaitai
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"reflect"
 	"testing"
@@ -747,3 +748,76 @@ func TestStream_ReadBitsInt(t *testing.T) {
 		})
 	}
 }
+
+func TestStream_endOfStream(t *testing.T) {
+	tests := []struct {
+		name  string
+		read  func(k *Stream) error
+		want  EndOfStreamError
+		isEOF bool
+	}{
+		{"ReadU1 at end", func(k *Stream) error {
+			_, err := k.ReadBytes(5)
+			if err == nil {
+				_, err = k.ReadU1()
+			}
+			return err
+		}, EndOfStreamError{"ReadU1", 5, 1, 0, io.EOF}, true},
+		{"ReadU4le", func(k *Stream) error {
+			_, err := k.ReadU2be()
+			if err == nil {
+				_, err = k.ReadU4le()
+			}
+			return err
+		}, EndOfStreamError{"ReadU4le", 2, 4, 3, io.ErrUnexpectedEOF}, false},
+		{"ReadU8be", func(k *Stream) error { _, err := k.ReadU8be(); return err }, EndOfStreamError{"ReadU8be", 0, 8, 5, io.ErrUnexpectedEOF}, false},
+		{"ReadBytes", func(k *Stream) error { _, err := k.ReadBytes(6); return err }, EndOfStreamError{"ReadBytes", 0, 6, 5, io.ErrUnexpectedEOF}, false},
+		{"ReadBitsIntBe", func(k *Stream) error { _, err := k.ReadBitsIntBe(64); return err }, EndOfStreamError{"ReadBitsIntBe", 0, 8, 5, io.ErrUnexpectedEOF}, false},
+		{"ReadBitsIntLe", func(k *Stream) error { _, err := k.ReadBitsIntLe(48); return err }, EndOfStreamError{"ReadBitsIntLe", 0, 6, 5, io.ErrUnexpectedEOF}, false},
+		{"ReadBytesTerm", func(k *Stream) error {
+			_, err := k.ReadBytesTerm(0xff, false, true, true)
+			return err
+		}, EndOfStreamError{"ReadBytesTerm", 0, 6, 5, io.EOF}, true},
+		{"ReadBytesTermMulti", func(k *Stream) error {
+			_, err := k.ReadBytesTermMulti([]byte{0xff, 0xff}, false, true, true)
+			return err
+		}, EndOfStreamError{"ReadBytesTermMulti", 0, 6, 5, io.ErrUnexpectedEOF}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.read(NewStream(bytes.NewReader([]byte{1, 2, 3, 4, 5})))
+			var got EndOfStreamError
+			if !errors.As(err, &got) {
+				t.Fatalf("error = %v, want EndOfStreamError", err)
+			}
+			if got != tt.want {
+				t.Errorf("error = %#v, want %#v", got, tt.want)
+			}
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("errors.Is(%v, io.ErrUnexpectedEOF) = false, want true", err)
+			}
+			if errors.Is(err, io.EOF) != tt.isEOF {
+				t.Errorf("errors.Is(%v, io.EOF) = %v, want %v", err, !tt.isEOF, tt.isEOF)
+			}
+		})
+	}
+}
+
+// errReader fails every read with err.
+type errReader struct {
+	io.ReadSeeker
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestStream_readError(t *testing.T) {
+	k := NewStream(errReader{bytes.NewReader(nil), io.ErrClosedPipe})
+	_, err := k.ReadU2le()
+	if !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("error = %v, want %v", err, io.ErrClosedPipe)
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("errors.Is(%v, io.ErrUnexpectedEOF) = true, want false", err)
+	}
+}
@@ -2,24 +2,102 @@ package kaitai
 
 import (
 	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"compress/lzw"
 	"compress/zlib"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"math/bits"
+	"sync"
+	"unicode/utf8"
 
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/transform"
 )
 
-// ProcessXOR returns data xored with the key.
+// ErrEmptyKey is returned by the XOR process functions when the key is
+// empty.
+var ErrEmptyKey = errors.New("empty XOR key")
+
+// ProcessXOR returns data xored with the key, which is repeated as needed.
+// An empty key leaves the data unchanged; use ProcessXORChecked to have it
+// reported as an error instead.
 func ProcessXOR(data []byte, key []byte) []byte {
 	out := make([]byte, len(data))
-	for i := range data {
-		out[i] = data[i] ^ key[i%len(key)]
+	if len(key) == 0 {
+		copy(out, data)
+		return out
 	}
+	xorKey(out, data, key, 0)
 	return out
 }
 
+// ProcessXORChecked is like ProcessXOR, but fails with ErrEmptyKey if the
+// key is empty.
+func ProcessXORChecked(data []byte, key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("ProcessXORChecked: %w", ErrEmptyKey)
+	}
+	return ProcessXOR(data, key), nil
+}
+
+// ProcessXORInPlace xors data with the key like ProcessXORChecked, but
+// modifies data instead of allocating the output.
+func ProcessXORInPlace(data []byte, key []byte) error {
+	if len(key) == 0 {
+		return fmt.Errorf("ProcessXORInPlace: %w", ErrEmptyKey)
+	}
+	xorKey(data, data, key, 0)
+	return nil
+}
+
+// xorPatternSize is the minimum length to which short keys are expanded, so
+// that they can be applied a machine word at a time.
+const xorPatternSize = 64
+
+// xorKey sets dst[i] to src[i] ^ key[(phase+i)%len(key)] and returns the
+// phase of the key for the byte following src. dst and src may be the same
+// slice. The key must not be empty.
+func xorKey(dst, src, key []byte, phase int) int {
+	if len(src) < xorPatternSize {
+		for i := range src {
+			dst[i] = src[i] ^ key[phase]
+			phase++
+			if phase == len(key) {
+				phase = 0
+			}
+		}
+		return phase
+	}
+	end := (phase + len(src)) % len(key)
+
+	// Repeat short keys, including single-byte ones, so that each call to
+	// subtle.XORBytes covers enough bytes to work on whole words. A whole
+	// number of repetitions leaves the phase unchanged.
+	if len(key) < xorPatternSize {
+		var pattern [2 * xorPatternSize]byte
+		n := 0
+		for n < xorPatternSize {
+			n += copy(pattern[n:], key)
+		}
+		key = pattern[:n]
+	}
+
+	i := 0
+	if phase != 0 {
+		i = subtle.XORBytes(dst, src, key[phase:])
+	}
+	for i < len(src) {
+		i += subtle.XORBytes(dst[i:], src[i:], key)
+	}
+	return end
+}
+
 // ProcessRotateLeft returns the single bytes in data rotated left by
 // amount bits.
 func ProcessRotateLeft(data []byte, amount int) []byte {
@@ -36,24 +114,283 @@ func ProcessRotateRight(data []byte, amount int) []byte {
 	return ProcessRotateLeft(data, -amount)
 }
 
-// ProcessZlib decompresses the given bytes as specified in RFC 1950.
+// ErrGroupSize is returned by the group rotation functions when the group
+// size is not supported or the data can't be split into whole groups.
+var ErrGroupSize = errors.New("invalid group size")
+
+// ProcessRotateLeftGroupBe splits data into big-endian unsigned integers of
+// groupSize bytes, which must be 1, 2, 4 or 8, and returns them rotated left
+// by amount bits. The length of data must be a multiple of groupSize.
+func ProcessRotateLeftGroupBe(data []byte, amount, groupSize int) ([]byte, error) {
+	return rotateGroups("ProcessRotateLeftGroupBe", data, amount, groupSize, binary.BigEndian)
+}
+
+// ProcessRotateLeftGroupLe is like ProcessRotateLeftGroupBe, but for
+// little-endian groups.
+func ProcessRotateLeftGroupLe(data []byte, amount, groupSize int) ([]byte, error) {
+	return rotateGroups("ProcessRotateLeftGroupLe", data, amount, groupSize, binary.LittleEndian)
+}
+
+// ProcessRotateRightGroupBe is like ProcessRotateLeftGroupBe, but rotates
+// right.
+func ProcessRotateRightGroupBe(data []byte, amount, groupSize int) ([]byte, error) {
+	return rotateGroups("ProcessRotateRightGroupBe", data, -amount, groupSize, binary.BigEndian)
+}
+
+// ProcessRotateRightGroupLe is like ProcessRotateLeftGroupLe, but rotates
+// right.
+func ProcessRotateRightGroupLe(data []byte, amount, groupSize int) ([]byte, error) {
+	return rotateGroups("ProcessRotateRightGroupLe", data, -amount, groupSize, binary.LittleEndian)
+}
+
+// UnprocessRotateLeftGroupBe reverses ProcessRotateLeftGroupBe.
+func UnprocessRotateLeftGroupBe(data []byte, amount, groupSize int) ([]byte, error) {
+	return rotateGroups("UnprocessRotateLeftGroupBe", data, -amount, groupSize, binary.BigEndian)
+}
+
+// UnprocessRotateLeftGroupLe reverses ProcessRotateLeftGroupLe.
+func UnprocessRotateLeftGroupLe(data []byte, amount, groupSize int) ([]byte, error) {
+	return rotateGroups("UnprocessRotateLeftGroupLe", data, -amount, groupSize, binary.LittleEndian)
+}
+
+// UnprocessRotateRightGroupBe reverses ProcessRotateRightGroupBe.
+func UnprocessRotateRightGroupBe(data []byte, amount, groupSize int) ([]byte, error) {
+	return rotateGroups("UnprocessRotateRightGroupBe", data, amount, groupSize, binary.BigEndian)
+}
+
+// UnprocessRotateRightGroupLe reverses ProcessRotateRightGroupLe.
+func UnprocessRotateRightGroupLe(data []byte, amount, groupSize int) ([]byte, error) {
+	return rotateGroups("UnprocessRotateRightGroupLe", data, amount, groupSize, binary.LittleEndian)
+}
+
+// rotateGroups rotates the groupSize-byte integers in data, stored in the
+// given byte order, left by amount bits. Negative amounts rotate right.
+func rotateGroups(method string, data []byte, amount, groupSize int, order binary.ByteOrder) ([]byte, error) {
+	switch groupSize {
+	case 1, 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("%s: group size %d is not 1, 2, 4 or 8: %w", method, groupSize, ErrGroupSize)
+	}
+	if len(data)%groupSize != 0 {
+		return nil, fmt.Errorf("%s: data length %d is not a multiple of group size %d: %w", method, len(data), groupSize, ErrGroupSize)
+	}
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i += groupSize {
+		switch groupSize {
+		case 1:
+			out[i] = bits.RotateLeft8(data[i], amount)
+		case 2:
+			order.PutUint16(out[i:], bits.RotateLeft16(order.Uint16(data[i:]), amount))
+		case 4:
+			order.PutUint32(out[i:], bits.RotateLeft32(order.Uint32(data[i:]), amount))
+		case 8:
+			order.PutUint64(out[i:], bits.RotateLeft64(order.Uint64(data[i:]), amount))
+		}
+	}
+	return out, nil
+}
+
+// zlibReaders pools zlib decompressors, as each one allocates a considerable
+// amount of memory which can be reused through zlib.Resetter.
+var zlibReaders sync.Pool
+
+// ProcessZlib decompresses the given bytes as specified in RFC 1950. It is
+// safe for concurrent use. The size of the output is not limited; use
+// ProcessZlibMax for untrusted input.
 func ProcessZlib(in []byte) ([]byte, error) {
+	return processZlib("ProcessZlib", in, 0)
+}
+
+// ProcessZlibMax is like ProcessZlib, but if maxSize is positive, producing
+// more than maxSize bytes fails with ErrMaxSizeExceeded.
+func ProcessZlibMax(in []byte, maxSize int64) ([]byte, error) {
+	return processZlib("ProcessZlibMax", in, maxSize)
+}
+
+func processZlib(method string, in []byte, maxSize int64) ([]byte, error) {
 	b := bytes.NewReader(in)
 
-	// FIXME zlib.NewReader allocates a bunch of memory.  In the future
-	// we could reuse it by using a sync.Pool if this is called in a tight loop.
-	r, err := zlib.NewReader(b)
+	r, err := newZlibReader(b)
+	if r != nil {
+		defer zlibReaders.Put(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: error initializing zlib reader: %w", method, err)
+	}
+
+	res, err := io.ReadAll(limitReader(r, maxSize))
+	if err != nil {
+		return nil, fmt.Errorf("%s: error reading zlib data: %w", method, err)
+	}
+	return res, nil
+}
+
+// newZlibReader returns a zlib decompressor reading from r, reusing one from
+// zlibReaders if possible. The returned reader should be put back into the
+// pool once it's no longer used, even if err is not nil.
+func newZlibReader(r io.Reader) (io.ReadCloser, error) {
+	if zr, ok := zlibReaders.Get().(io.ReadCloser); ok {
+		return zr, zr.(zlib.Resetter).Reset(r, nil)
+	}
+	return zlib.NewReader(r)
+}
+
+// ProcessDeflate decompresses the given raw deflate bytes, as specified in
+// RFC 1951 and found e.g. in ZIP entries. If maxSize is positive, producing
+// more than maxSize bytes fails with ErrMaxSizeExceeded.
+func ProcessDeflate(in []byte, maxSize int64) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(in))
+	defer r.Close()
+
+	res, err := io.ReadAll(limitReader(r, maxSize))
 	if err != nil {
-		return nil, fmt.Errorf("ProcessZlib: error initializing zlib reader: %w", err)
+		return nil, fmt.Errorf("ProcessDeflate: error reading deflate data: %w", err)
 	}
+	return res, nil
+}
 
-	res, err := io.ReadAll(r)
+// ProcessGzip decompresses the given bytes as specified in RFC 1952.
+// Concatenated gzip members are decompressed as one. If maxSize is positive,
+// producing more than maxSize bytes fails with ErrMaxSizeExceeded.
+func ProcessGzip(in []byte, maxSize int64) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(in))
 	if err != nil {
-		return nil, fmt.Errorf("ProcessZlib: error reading zlib data: %w", err)
+		return nil, fmt.Errorf("ProcessGzip: error initializing gzip reader: %w", err)
+	}
+	defer r.Close()
+
+	res, err := io.ReadAll(limitReader(r, maxSize))
+	if err != nil {
+		return nil, fmt.Errorf("ProcessGzip: error reading gzip data: %w", err)
 	}
 	return res, nil
 }
 
+// ProcessBzip2 decompresses the given bzip2 bytes. If maxSize is positive,
+// producing more than maxSize bytes fails with ErrMaxSizeExceeded.
+func ProcessBzip2(in []byte, maxSize int64) ([]byte, error) {
+	r := bzip2.NewReader(bytes.NewReader(in))
+
+	res, err := io.ReadAll(limitReader(r, maxSize))
+	if err != nil {
+		return nil, fmt.Errorf("ProcessBzip2: error reading bzip2 data: %w", err)
+	}
+	return res, nil
+}
+
+// ProcessLzw decompresses the given LZW bytes. order and litWidth are
+// interpreted as in compress/lzw: GIF uses lzw.LSB and the image's code
+// size, TIFF and PDF use lzw.MSB and 8. If earlyChange is true, the code
+// width grows one code earlier, as required by TIFF and by PDF's default
+// EarlyChange setting. If maxSize is positive, producing more than maxSize
+// bytes fails with ErrMaxSizeExceeded.
+func ProcessLzw(in []byte, order lzw.Order, litWidth int, earlyChange bool, maxSize int64) ([]byte, error) {
+	if earlyChange {
+		res, err := decodeLzwEarlyChange(in, order, litWidth, maxSize)
+		if err != nil {
+			return nil, fmt.Errorf("ProcessLzw: error reading lzw data: %w", err)
+		}
+		return res, nil
+	}
+
+	r := lzw.NewReader(bytes.NewReader(in), order, litWidth)
+	defer r.Close()
+
+	res, err := io.ReadAll(limitReader(r, maxSize))
+	if err != nil {
+		return nil, fmt.Errorf("ProcessLzw: error reading lzw data: %w", err)
+	}
+	return res, nil
+}
+
+// UnprocessZlib compresses the given bytes as specified in RFC 1950, using
+// one of the compression levels defined in compress/zlib. It is the inverse
+// of ProcessZlib.
+func UnprocessZlib(data []byte, level int) ([]byte, error) {
+	var b bytes.Buffer
+	w, err := zlib.NewWriterLevel(&b, level)
+	if err != nil {
+		return nil, fmt.Errorf("UnprocessZlib: error initializing zlib writer: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("UnprocessZlib: error writing zlib data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("UnprocessZlib: error finishing zlib data: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// ZlibHeader holds the parameters stored in the 2-byte header of a zlib
+// stream.
+type ZlibHeader struct {
+	// WindowSize is the LZ77 window size in bytes.
+	WindowSize int
+	// Level is the FLEVEL field: 0 = fastest, 1 = fast, 2 = default and
+	// 3 = maximum compression.
+	Level int
+	// Dict is true if a preset dictionary was used.
+	Dict bool
+}
+
+// ParseZlibHeader parses the header of the zlib stream in.
+func ParseZlibHeader(in []byte) (ZlibHeader, error) {
+	if len(in) < 2 {
+		return ZlibHeader{}, fmt.Errorf("ParseZlibHeader: %w", zlib.ErrHeader)
+	}
+	cmf, flg := in[0], in[1]
+	if cmf&0x0f != 8 || cmf>>4 > 7 || (uint16(cmf)<<8|uint16(flg))%31 != 0 {
+		return ZlibHeader{}, fmt.Errorf("ParseZlibHeader: %w", zlib.ErrHeader)
+	}
+	return ZlibHeader{
+		WindowSize: 1 << (cmf>>4 + 8),
+		Level:      int(flg >> 6),
+		Dict:       flg&0x20 != 0,
+	}, nil
+}
+
+// zlibLevels lists the compress/zlib levels producing each FLEVEL header
+// value, most likely first.
+var zlibLevels = [4][]int{
+	{zlib.BestSpeed, zlib.NoCompression, zlib.HuffmanOnly},
+	{5, 4, 3, 2},
+	{zlib.DefaultCompression},
+	{zlib.BestCompression, 8, 7},
+}
+
+// UnprocessZlibLike compresses data like UnprocessZlib, choosing the
+// compression level from the header of original, the zlib stream data was
+// originally decompressed from. Every level matching the header is tried and
+// if one reproduces original byte for byte, its output is returned;
+// otherwise the output of the most likely level is. An exact match is only
+// possible if original was produced by compress/zlib with the default
+// 32 KiB window; other compressors generally emit different, though
+// equivalent, streams.
+func UnprocessZlibLike(data, original []byte) ([]byte, error) {
+	h, err := ParseZlibHeader(original)
+	if err != nil {
+		return nil, fmt.Errorf("UnprocessZlibLike: %w", err)
+	}
+	levels := zlibLevels[h.Level]
+	if h.WindowSize != 1<<15 || h.Dict {
+		levels = levels[:1]
+	}
+	var first []byte
+	for _, level := range levels {
+		res, err := UnprocessZlib(data, level)
+		if err != nil {
+			return nil, fmt.Errorf("UnprocessZlibLike: %w", err)
+		}
+		if bytes.Equal(res, original) {
+			return res, nil
+		}
+		if first == nil {
+			first = res
+		}
+	}
+	return first, nil
+}
+
 // BytesToStr returns a string decoded by the given decoder.
 func BytesToStr(in []byte, decoder *encoding.Decoder) (string, error) {
 	i := bytes.NewReader(in)
@@ -65,6 +402,33 @@ func BytesToStr(in []byte, decoder *encoding.Decoder) (string, error) {
 	return string(d), nil
 }
 
+// StrToBytes returns the string s encoded by the given encoder. If s
+// contains a rune that cannot be represented in the target encoding, the
+// returned error wraps an UnencodableRuneError describing it.
+func StrToBytes(s string, encoder *encoding.Encoder) ([]byte, error) {
+	res, n, err := transform.String(encoder, s)
+	if err != nil {
+		if n < len(s) {
+			r, _ := utf8.DecodeRuneInString(s[n:])
+			err = UnencodableRuneError{utf8.RuneCountInString(s[:n]), r, err}
+		}
+		return nil, fmt.Errorf("StrToBytes: error encoding string with %T: %w", encoder.Transformer, err)
+	}
+	return []byte(res), nil
+}
+
+// StrToBytesLossy returns the string s encoded by the given encoder. Runes
+// that cannot be represented in the target encoding are replaced with the
+// encoding's replacement character instead of causing an error.
+func StrToBytesLossy(s string, encoder *encoding.Encoder) ([]byte, error) {
+	lossy := encoding.ReplaceUnsupported(encoder)
+	res, _, err := transform.String(lossy, s)
+	if err != nil {
+		return nil, fmt.Errorf("StrToBytesLossy: error encoding string with %T: %w", encoder.Transformer, err)
+	}
+	return []byte(res), nil
+}
+
 // StringReverse returns the string s in reverse order.
 func StringReverse(s string) string {
 	r := []rune(s)
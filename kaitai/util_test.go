@@ -1,10 +1,20 @@
 package kaitai
 
 import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/lzw"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
 	"reflect"
+	"sync"
 	"testing"
 
 	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/encoding/unicode"
 )
 
@@ -13,19 +23,86 @@ func TestProcessXOR(t *testing.T) {
 		data []byte
 		key  []byte
 	}
+	long := bytes.Repeat([]byte{0x5a, 0x00, 0xff}, 100)
 	tests := []struct {
-		name string
-		args args
-		want []byte
+		name    string
+		args    args
+		want    []byte
+		wantErr error
 	}{
-		{"Simple XOR", args{[]byte{0x1}, []byte{0x2}}, []byte{0x3}},
-		{"Another simple XOR", args{[]byte{0x1}, []byte{0x1}}, []byte{0x0}},
+		{"Simple XOR", args{[]byte{0x1}, []byte{0x2}}, []byte{0x3}, nil},
+		{"Another simple XOR", args{[]byte{0x1}, []byte{0x1}}, []byte{0x0}, nil},
+		{"Short key repeated", args{[]byte{1, 2, 3, 4, 5}, []byte{1, 2}}, []byte{0, 0, 2, 6, 4}, nil},
+		{"Single-byte key on long data", args{long, []byte{0xff}}, xorNaive(long, []byte{0xff}), nil},
+		{"Multi-byte key on long data", args{long, []byte{1, 2, 3, 4, 5, 6, 7}}, xorNaive(long, []byte{1, 2, 3, 4, 5, 6, 7}), nil},
+		{"Key longer than pattern", args{long, long[:100]}, xorNaive(long, long[:100]), nil},
+		{"Key longer than data", args{long[:70], long}, xorNaive(long[:70], long), nil},
+		{"Empty data", args{[]byte{}, []byte{1}}, []byte{}, nil},
+		{"Empty key", args{[]byte{1}, nil}, nil, ErrEmptyKey},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := ProcessXOR(tt.args.data, tt.args.key); !reflect.DeepEqual(got, tt.want) {
+			got, err := ProcessXORChecked(tt.args.data, tt.args.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ProcessXORChecked() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProcessXORChecked() = %v, want %v", got, tt.want)
+			}
+			if err != nil {
+				// ProcessXOR leaves the data unchanged instead
+				if got := ProcessXOR(tt.args.data, tt.args.key); !bytes.Equal(got, tt.args.data) {
+					t.Errorf("ProcessXOR() = %v, want %v", got, tt.args.data)
+				}
+				return
+			}
+			if got := ProcessXOR(tt.args.data, tt.args.key); !bytes.Equal(got, tt.want) {
 				t.Errorf("ProcessXOR() = %v, want %v", got, tt.want)
 			}
+			inPlace := bytes.Clone(tt.args.data)
+			if err := ProcessXORInPlace(inPlace, tt.args.key); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(inPlace, tt.want) {
+				t.Errorf("ProcessXORInPlace() = %v, want %v", inPlace, tt.want)
+			}
+		})
+	}
+}
+
+func xorNaive(data, key []byte) []byte {
+	out := make([]byte, len(data))
+	for i := range data {
+		out[i] = data[i] ^ key[i%len(key)]
+	}
+	return out
+}
+
+func Test_xorKey_phase(t *testing.T) {
+	data := bytes.Repeat([]byte{0xaa}, 500)
+	key := []byte{1, 2, 3, 4, 5}
+	want := xorNaive(data, key)
+	for _, split := range []int{1, 3, 64, 65, 199, 499} {
+		got := make([]byte, len(data))
+		phase := xorKey(got[:split], data[:split], key, 0)
+		xorKey(got[split:], data[split:], key, phase)
+		if !bytes.Equal(got, want) {
+			t.Errorf("xorKey() split at %d = %v, want %v", split, got, want)
+		}
+	}
+}
+
+func BenchmarkProcessXOR(b *testing.B) {
+	data := make([]byte, 64<<10)
+	for _, keyLen := range []int{1, 4, 16, 256} {
+		key := bytes.Repeat([]byte{0x5a}, keyLen)
+		b.Run(fmt.Sprintf("key%d", keyLen), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if err := ProcessXORInPlace(data, key); err != nil {
+					b.Fatal(err)
+				}
+			}
 		})
 	}
 }
@@ -72,6 +149,52 @@ func TestProcessRotateRight(t *testing.T) {
 	}
 }
 
+func TestProcessRotateGroup(t *testing.T) {
+	data := []byte{0x81, 0x02, 0x03, 0x84}
+	type rotateFunc func([]byte, int, int) ([]byte, error)
+	tests := []struct {
+		name      string
+		rotate    rotateFunc
+		unrotate  rotateFunc
+		amount    int
+		groupSize int
+		want      []byte
+		wantErr   error
+	}{
+		{"left be 1", ProcessRotateLeftGroupBe, UnprocessRotateLeftGroupBe, 1, 1, []byte{0x03, 0x04, 0x06, 0x09}, nil},
+		{"left be 2", ProcessRotateLeftGroupBe, UnprocessRotateLeftGroupBe, 1, 2, []byte{0x02, 0x05, 0x07, 0x08}, nil},
+		{"left le 2", ProcessRotateLeftGroupLe, UnprocessRotateLeftGroupLe, 1, 2, []byte{0x02, 0x05, 0x07, 0x08}, nil},
+		{"left be 4", ProcessRotateLeftGroupBe, UnprocessRotateLeftGroupBe, 4, 4, []byte{0x10, 0x20, 0x38, 0x48}, nil},
+		{"left le 4", ProcessRotateLeftGroupLe, UnprocessRotateLeftGroupLe, 8, 4, []byte{0x84, 0x81, 0x02, 0x03}, nil},
+		{"right be 4", ProcessRotateRightGroupBe, UnprocessRotateRightGroupBe, 8, 4, []byte{0x84, 0x81, 0x02, 0x03}, nil},
+		{"right le 4", ProcessRotateRightGroupLe, UnprocessRotateRightGroupLe, 8, 4, []byte{0x02, 0x03, 0x84, 0x81}, nil},
+		{"right le 2", ProcessRotateRightGroupLe, UnprocessRotateRightGroupLe, 17, 2, []byte{0x40, 0x81, 0x01, 0xc2}, nil},
+		{"length not a multiple", ProcessRotateLeftGroupBe, UnprocessRotateLeftGroupBe, 1, 8, nil, ErrGroupSize},
+		{"unsupported group size", ProcessRotateLeftGroupLe, UnprocessRotateLeftGroupLe, 1, 3, nil, ErrGroupSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.rotate(data, tt.amount, tt.groupSize)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("rotate error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rotate = %x, want %x", got, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			orig, err := tt.unrotate(got, tt.amount, tt.groupSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(orig, data) {
+				t.Errorf("unrotate = %x, want %x", orig, data)
+			}
+		})
+	}
+}
+
 func TestProcessZlib(t *testing.T) {
 	type args struct {
 		in []byte
@@ -101,6 +224,355 @@ func TestProcessZlib(t *testing.T) {
 	}
 }
 
+func TestProcessZlibMax(t *testing.T) {
+	want := bytes.Repeat([]byte("goodbye, world "), 100)
+	in, err := UnprocessZlib(want, zlib.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		maxSize int64
+		want    []byte
+		wantErr error
+	}{
+		{"No limit", 0, want, nil},
+		{"Within limit", int64(len(want)), want, nil},
+		{"Over limit", int64(len(want)) - 1, nil, ErrMaxSizeExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProcessZlibMax(in, tt.maxSize)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("ProcessZlibMax() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProcessZlibMax() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessZlib_concurrent(t *testing.T) {
+	var inputs [][]byte
+	var wants [][]byte
+	for i := 0; i < 8; i++ {
+		want := bytes.Repeat([]byte{byte(i)}, 1000*(i+1))
+		in, err := UnprocessZlib(want, zlib.DefaultCompression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, in)
+		wants = append(wants, want)
+	}
+	inputs = append(inputs, []byte{0x00})
+	wants = append(wants, nil)
+
+	var wg sync.WaitGroup
+	for i := range inputs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				got, err := ProcessZlib(inputs[i])
+				if (err != nil) != (wants[i] == nil) {
+					t.Errorf("ProcessZlib() error = %v", err)
+					return
+				}
+				if !bytes.Equal(got, wants[i]) {
+					t.Errorf("ProcessZlib() returned %d bytes, want %d", len(got), len(wants[i]))
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func zlibBenchmarkInput(b *testing.B) []byte {
+	b.Helper()
+	in, err := UnprocessZlib(bytes.Repeat([]byte("IDAT chunk payload "), 20), zlib.DefaultCompression)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return in
+}
+
+func BenchmarkProcessZlib(b *testing.B) {
+	in := zlibBenchmarkInput(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ProcessZlib(in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkProcessZlib_unpooled is the baseline for BenchmarkProcessZlib,
+// allocating a new decompressor for every call.
+func BenchmarkProcessZlib_unpooled(b *testing.B) {
+	in := zlibBenchmarkInput(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r, err := zlib.NewReader(bytes.NewReader(in))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.ReadAll(r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessZlib_parallel(b *testing.B) {
+	in := zlibBenchmarkInput(b)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := ProcessZlib(in); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func TestProcessDeflate(t *testing.T) {
+	want := bytes.Repeat([]byte("goodbye, world "), 100)
+	var b bytes.Buffer
+	w, err := flate.NewWriter(&b, flate.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		in      []byte
+		maxSize int64
+		want    []byte
+		wantErr error
+	}{
+		{"Simple Deflate", b.Bytes(), 0, want, nil},
+		{"Within limit", b.Bytes(), int64(len(want)), want, nil},
+		{"Over limit", b.Bytes(), int64(len(want)) - 1, nil, ErrMaxSizeExceeded},
+		{"Truncated input", b.Bytes()[:10], 0, nil, io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProcessDeflate(tt.in, tt.maxSize)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("ProcessDeflate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProcessDeflate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessGzip(t *testing.T) {
+	var b bytes.Buffer
+	for _, member := range []string{"goodbye, ", "world"} {
+		w := gzip.NewWriter(&b)
+		if _, err := w.Write([]byte(member)); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name    string
+		in      []byte
+		maxSize int64
+		want    []byte
+		wantErr error
+	}{
+		{"Two members", b.Bytes(), 0, []byte("goodbye, world"), nil},
+		{"Over limit", b.Bytes(), 10, nil, ErrMaxSizeExceeded},
+		{"Wrong input", []byte("definitely not gzip"), 0, nil, gzip.ErrHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProcessGzip(tt.in, tt.maxSize)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("ProcessGzip() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProcessGzip() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessBzip2(t *testing.T) {
+	// Output of Python's bz2.compress(b"goodbye, world")
+	in := []byte{0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x20, 0xb9, 0xa5, 0x6e,
+		0x00, 0x00, 0x03, 0x11, 0x80, 0x40, 0x04, 0x16, 0x84, 0x90, 0xa0, 0x20, 0x00, 0x31, 0x00, 0xd3,
+		0x4d, 0x04, 0x06, 0x83, 0x24, 0x01, 0xba, 0xa8, 0x82, 0xa4, 0xaf, 0x17, 0x72, 0x45, 0x38, 0x50,
+		0x90, 0x20, 0xb9, 0xa5, 0x6e}
+	tests := []struct {
+		name    string
+		in      []byte
+		maxSize int64
+		want    []byte
+		wantErr bool
+	}{
+		{"Simple Bzip2", in, 0, []byte("goodbye, world"), false},
+		{"Over limit", in, 5, nil, true},
+		{"Wrong input", []byte("BZh9garbage"), 0, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProcessBzip2(tt.in, tt.maxSize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessBzip2() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProcessBzip2() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessLzw(t *testing.T) {
+	gifData := lzwTestData(5000, 16)
+	var gif bytes.Buffer
+	w := lzw.NewWriter(&gif, lzw.LSB, 8)
+	if _, err := w.Write(gifData); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tiffData := lzwTestData(5000, 64)
+
+	type args struct {
+		in          []byte
+		order       lzw.Order
+		litWidth    int
+		earlyChange bool
+		maxSize     int64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []byte
+		wantErr bool
+	}{
+		{"GIF", args{gif.Bytes(), lzw.LSB, 8, false, 0}, gifData, false},
+		{
+			// Example from section 13 of the TIFF 6.0 specification
+			"TIFF specification example",
+			args{[]byte{0x80, 0x01, 0xe0, 0x40, 0x80, 0x44, 0x08, 0x0c, 0x06, 0x80, 0x80}, lzw.MSB, 8, true, 0},
+			[]byte{7, 7, 7, 8, 8, 7, 7, 6, 6}, false,
+		},
+		{"TIFF", args{encodeLzwTIFF(tiffData), lzw.MSB, 8, true, 0}, tiffData, false},
+		{"TIFF over limit", args{encodeLzwTIFF(tiffData), lzw.MSB, 8, true, 4999}, nil, true},
+		{"GIF over limit", args{gif.Bytes(), lzw.LSB, 8, false, 4999}, nil, true},
+		{"Invalid code", args{[]byte{0x80, 0x7f, 0xff}, lzw.MSB, 8, true, 0}, nil, true},
+		{"Truncated", args{[]byte{0x80, 0x01, 0xe0}, lzw.MSB, 8, true, 0}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProcessLzw(tt.args.in, tt.args.order, tt.args.litWidth, tt.args.earlyChange, tt.args.maxSize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessLzw() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProcessLzw() returned %d bytes, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestUnprocessZlib(t *testing.T) {
+	data := bytes.Repeat([]byte("goodbye, world "), 10)
+	for _, level := range []int{zlib.HuffmanOnly, zlib.DefaultCompression, zlib.NoCompression, zlib.BestSpeed, zlib.BestCompression} {
+		compressed, err := UnprocessZlib(data, level)
+		if err != nil {
+			t.Fatalf("UnprocessZlib(level %d) error = %v", level, err)
+		}
+		got, err := ProcessZlib(compressed)
+		if err != nil {
+			t.Fatalf("ProcessZlib() error = %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("ProcessZlib(UnprocessZlib(level %d)) = %q, want %q", level, got, data)
+		}
+	}
+	if _, err := UnprocessZlib(data, 10); err == nil {
+		t.Error("UnprocessZlib(level 10) error = nil, want error")
+	}
+}
+
+func TestParseZlibHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []byte
+		want    ZlibHeader
+		wantErr bool
+	}{
+		{"default", []byte{0x78, 0x9c}, ZlibHeader{32768, 2, false}, false},
+		{"best", []byte{0x78, 0xda}, ZlibHeader{32768, 3, false}, false},
+		{"fastest", []byte{0x78, 0x01}, ZlibHeader{32768, 0, false}, false},
+		{"small window", []byte{0x28, 0x15}, ZlibHeader{1024, 0, false}, false},
+		{"dictionary", []byte{0x78, 0xbb}, ZlibHeader{32768, 2, true}, false},
+		{"bad checksum", []byte{0x78, 0x9d}, ZlibHeader{}, true},
+		{"not deflate", []byte{0x79, 0x9c}, ZlibHeader{}, true},
+		{"too short", []byte{0x78}, ZlibHeader{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseZlibHeader(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseZlibHeader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseZlibHeader() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnprocessZlibLike(t *testing.T) {
+	data := bytes.Repeat([]byte("goodbye, world "), 100)
+	for _, level := range []int{zlib.NoCompression, zlib.BestSpeed, 3, zlib.DefaultCompression, 6, 8, zlib.BestCompression} {
+		original, err := UnprocessZlib(data, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := UnprocessZlibLike(data, original)
+		if err != nil {
+			t.Fatalf("UnprocessZlibLike(level %d) error = %v", level, err)
+		}
+		if !bytes.Equal(got, original) {
+			t.Errorf("UnprocessZlibLike(level %d) = %x, want %x", level, got, original)
+		}
+	}
+
+	// The stream of TestProcessZlib comes from C zlib, which compresses
+	// differently, so only equivalence can be expected
+	original := []byte{0x78, 0x9c, 0x4b, 0xcf, 0xcf, 0x4f, 0x49, 0xaa,
+		0x4c, 0xd5, 0x51, 0x28, 0xcf, 0x2f, 0xca, 0x49,
+		0x01, 0x00, 0x28, 0xa5, 0x05, 0x5e}
+	got, err := UnprocessZlibLike([]byte("goodbye, world"), original)
+	if err != nil {
+		t.Fatalf("UnprocessZlibLike() error = %v", err)
+	}
+	if h, _ := ParseZlibHeader(got); h.Level != 2 {
+		t.Errorf("UnprocessZlibLike() header level = %v, want %v", h.Level, 2)
+	}
+	if dec, err := ProcessZlib(got); err != nil || string(dec) != "goodbye, world" {
+		t.Errorf("ProcessZlib(UnprocessZlibLike()) = %q, %v", dec, err)
+	}
+}
+
 func TestBytesToStr(t *testing.T) {
 	utf16 := unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
 	type args struct {
@@ -131,6 +603,57 @@ func TestBytesToStr(t *testing.T) {
 	}
 }
 
+func TestStrToBytes(t *testing.T) {
+	type args struct {
+		s       string
+		encoder *encoding.Encoder
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []byte
+		wantErr *UnencodableRuneError
+	}{
+		{"UTF-8 encode", args{"test", unicode.UTF8.NewEncoder()}, []byte("test"), nil},
+		{"ISO-8859-1 encode", args{"caf\u00e9", charmap.ISO8859_1.NewEncoder()}, []byte{'c', 'a', 'f', 0xe9}, nil},
+		{
+			"ISO-8859-1 unencodable", args{"\u00e9 \u20ac", charmap.ISO8859_1.NewEncoder()}, nil,
+			&UnencodableRuneError{Index: 2, Rune: '\u20ac'},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StrToBytes(tt.args.s, tt.args.encoder)
+			if tt.wantErr != nil {
+				var runeErr UnencodableRuneError
+				if !errors.As(err, &runeErr) {
+					t.Fatalf("StrToBytes() error = %v, want UnencodableRuneError", err)
+				}
+				if runeErr.Index != tt.wantErr.Index || runeErr.Rune != tt.wantErr.Rune {
+					t.Errorf("StrToBytes() error = %v, want rune %q at index %d", err, tt.wantErr.Rune, tt.wantErr.Index)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("StrToBytes() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StrToBytes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrToBytesLossy(t *testing.T) {
+	got, err := StrToBytesLossy("a\u20acb", charmap.ISO8859_1.NewEncoder())
+	if err != nil {
+		t.Fatalf("StrToBytesLossy() error = %v", err)
+	}
+	if want := []byte{'a', 0x1a, 'b'}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StrToBytesLossy() = %v, want %v", got, want)
+	}
+}
+
 func TestStringReverse(t *testing.T) {
 	type args struct {
 		s string
@@ -0,0 +1,87 @@
+package kaitai
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A ValidationCollector records validation failures when parsing in lenient
+// mode, which is enabled by attaching it to a Stream with
+// SetValidationCollector. Parsing then continues after a failed check, and
+// all failures can be inspected once it is done.
+type ValidationCollector struct {
+	errs []error
+}
+
+// Add records a validation failure.
+func (c *ValidationCollector) Add(err error) {
+	c.errs = append(c.errs, err)
+}
+
+// Errors returns the recorded failures in the order they occurred.
+func (c *ValidationCollector) Errors() []error {
+	return c.errs
+}
+
+// Err returns the recorded failures as a ValidationErrors, or nil if there
+// were none.
+func (c *ValidationCollector) Err() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return ValidationErrors(append([]error(nil), c.errs...))
+}
+
+// ValidationErrors aggregates the failures recorded by a
+// ValidationCollector. errors.Is and errors.As look at each of them.
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	if len(e) == 1 {
+		return msgs[0]
+	}
+	return fmt.Sprintf("%d validation errors: %s", len(e), strings.Join(msgs, "; "))
+}
+
+func (e ValidationErrors) Unwrap() []error { return e }
+
+// SetValidationCollector enables lenient mode for the stream if c is not
+// nil, making ValidationFailed record errors in c instead of returning them.
+// Passing nil restores the default strict mode.
+func (k *Stream) SetValidationCollector(c *ValidationCollector) {
+	k.validations = c
+}
+
+// ValidationCollector returns the collector attached to the stream, or nil
+// in strict mode.
+func (k *Stream) ValidationCollector() *ValidationCollector {
+	return k.validations
+}
+
+// ValidationFailed handles the failure of a validation check. In strict mode
+// it returns err, which should be returned by the caller, while in lenient
+// mode it records err in the stream's ValidationCollector and returns nil, so
+// that parsing continues.
+func (k *Stream) ValidationFailed(err error) error {
+	if k.validations == nil {
+		return err
+	}
+	k.validations.Add(err)
+	return nil
+}
+
+// NewSubstream creates a Stream reading from r, which inherits the settings
+// of k, such as its ValidationCollector, WarningHandler and error context. It
+// should be used for streams created from the data of a field of k.
+func (k *Stream) NewSubstream(r io.ReadSeeker) *Stream {
+	sub := NewStream(r)
+	sub.validations = k.validations
+	sub.warnings = k.warnings
+	sub.errorContext = k.errorContext
+	return sub
+}
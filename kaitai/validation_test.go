@@ -0,0 +1,112 @@
+package kaitai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// parseChecked mimics generated code reading a u1 magic, which must be 0x7f,
+// followed by a u1 version in a substream, which must be at most 2.
+func parseChecked(io *Stream) (magic, version uint8, err error) {
+	if magic, err = io.ReadU1(); err != nil {
+		return 0, 0, err
+	}
+	if magic != 0x7f {
+		if err := io.ValidationFailed(NewValidationNotEqualError(uint8(0x7f), magic, io, "/seq/0")); err != nil {
+			return 0, 0, err
+		}
+	}
+	raw, err := io.ReadBytes(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	sub := io.NewSubstream(bytes.NewReader(raw))
+	if version, err = sub.ReadU1(); err != nil {
+		return 0, 0, err
+	}
+	if version > 2 {
+		if err := sub.ValidationFailed(NewValidationGreaterThanError(uint8(2), version, sub, "/types/body/seq/0")); err != nil {
+			return 0, 0, err
+		}
+	}
+	return magic, version, nil
+}
+
+func TestStream_ValidationFailed(t *testing.T) {
+	data := []byte{0x01, 0x05}
+
+	t.Run("strict", func(t *testing.T) {
+		_, _, err := parseChecked(NewStream(bytes.NewReader(data)))
+		if !errors.Is(err, ErrNotEqual) {
+			t.Errorf("error = %v, want %v", err, ErrNotEqual)
+		}
+	})
+
+	t.Run("lenient", func(t *testing.T) {
+		var c ValidationCollector
+		io := NewStream(bytes.NewReader(data))
+		io.SetValidationCollector(&c)
+		magic, version, err := parseChecked(io)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if magic != 0x01 || version != 0x05 {
+			t.Errorf("parsed %v, %v, want %v, %v", magic, version, 0x01, 0x05)
+		}
+		if got := len(c.Errors()); got != 2 {
+			t.Fatalf("len(ValidationCollector.Errors()) = %v, want %v", got, 2)
+		}
+
+		err = c.Err()
+		for _, kind := range []error{ErrValidationFailed, ErrNotEqual, ErrGreaterThan} {
+			if !errors.Is(err, kind) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", err, kind)
+			}
+		}
+		var ge ValidationGreaterThanError
+		if !errors.As(err, &ge) || ge.SrcPath() != "/types/body/seq/0" {
+			t.Errorf("errors.As(%v, ValidationGreaterThanError) failed", err)
+		}
+		want := "2 validation errors: /seq/0: at pos 1: validation failed: not equal, expected 127 (0x7f), but got 1 (0x1); " +
+			"/types/body/seq/0: at pos 1: validation failed: not in range, max 2 (0x2), but got 5 (0x5)"
+		if got := err.Error(); got != want {
+			t.Errorf("ValidationErrors.Error() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("lenient without failures", func(t *testing.T) {
+		var c ValidationCollector
+		io := NewStream(bytes.NewReader([]byte{0x7f, 0x01}))
+		io.SetValidationCollector(&c)
+		if _, _, err := parseChecked(io); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Err(); err != nil {
+			t.Errorf("ValidationCollector.Err() = %v, want nil", err)
+		}
+	})
+}
+
+func TestValidationErrors_MarshalJSON(t *testing.T) {
+	err := ValidationErrors{NewValidationNotAnyOfError(1, nil, "/seq/0"), NewValidationExprError(2, nil, "/seq/1")}
+	b, jerr := json.Marshal(err)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	var got struct {
+		Kind    string
+		Details struct {
+			Errors []struct{ Kind, SrcPath string }
+		}
+	}
+	if jerr := json.Unmarshal(b, &got); jerr != nil {
+		t.Fatal(jerr)
+	}
+	want := []struct{ Kind, SrcPath string }{{"ValidationNotAnyOfError", "/seq/0"}, {"ValidationExprError", "/seq/1"}}
+	if got.Kind != "ValidationErrors" || !reflect.DeepEqual(got.Details.Errors, want) {
+		t.Errorf("json.Marshal(ValidationErrors) = %s", b)
+	}
+}
@@ -0,0 +1,83 @@
+package kaitai
+
+import "fmt"
+
+// WarningKind describes the anomaly reported by a Warning.
+type WarningKind string
+
+// Kinds of warnings emitted by generated code and the runtime. Other kinds
+// may be defined by users.
+const (
+	WarningUnknownEnum    WarningKind = "unknown enum value"
+	WarningTrailingData   WarningKind = "trailing data"
+	WarningNonZeroPadding WarningKind = "non-zero padding"
+)
+
+// Warning reports an anomaly in the parsed data which doesn't fail parsing,
+// such as an unknown enum value. Like validation errors, it records the
+// position of the stream and the source path of the field.
+type Warning struct {
+	kind  WarningKind
+	value interface{}
+	locationInfo
+}
+
+// NewWarning creates a new Warning instance. value is the offending value,
+// or nil if there is none.
+func NewWarning(kind WarningKind, value interface{}, io *Stream, srcPath string) Warning {
+	return Warning{
+		kind,
+		value,
+		newLocationInfo(io, srcPath),
+	}
+}
+
+// Kind is a getter of the kind of the warning.
+func (w Warning) Kind() WarningKind { return w.kind }
+
+// Value is a getter of the offending value associated with the warning.
+func (w Warning) Value() interface{} { return w.value }
+
+func (w Warning) String() string {
+	msg := "warning: " + string(w.kind)
+	if w.value != nil {
+		msg += fmt.Sprintf(", got %s", formatValue(w.value))
+	}
+	return w.msgWithLocation(msg)
+}
+
+// A WarningHandler receives the warnings emitted for a Stream.
+type WarningHandler func(Warning)
+
+// Warnings collects warnings, e.g. with
+//
+//	var warnings kaitai.Warnings
+//	io.SetWarningHandler(warnings.Add)
+type Warnings []Warning
+
+// Add records w.
+func (ws *Warnings) Add(w Warning) {
+	*ws = append(*ws, w)
+}
+
+// SetWarningHandler makes h receive the warnings emitted for the stream.
+// Passing nil makes the stream ignore warnings, which is the default.
+func (k *Stream) SetWarningHandler(h WarningHandler) {
+	k.warnings = h
+}
+
+// WarningHandler returns the handler attached to the stream, or nil if
+// warnings are ignored.
+func (k *Stream) WarningHandler() WarningHandler {
+	return k.warnings
+}
+
+// Warn emits a warning of the given kind at the current position of the
+// stream to its WarningHandler, if any. value is the offending value, or nil
+// if there is none.
+func (k *Stream) Warn(kind WarningKind, value interface{}, srcPath string) {
+	if k.warnings == nil {
+		return
+	}
+	k.warnings(NewWarning(kind, value, k, srcPath))
+}
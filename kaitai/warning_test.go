@@ -0,0 +1,129 @@
+package kaitai
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// parseRecords mimics generated code reading u1 records with an enum kind
+// from a substream until the end, warning about unknown kinds and data
+// remaining in io afterwards.
+func parseRecords(io *Stream) ([]uint8, error) {
+	raw, err := io.ReadBytes(3)
+	if err != nil {
+		return nil, err
+	}
+	sub := io.NewSubstream(bytes.NewReader(raw))
+	var kinds []uint8
+	for {
+		eof, err := sub.EOF()
+		if err != nil {
+			return nil, err
+		}
+		if eof {
+			break
+		}
+		kind, err := sub.ReadU1()
+		if err != nil {
+			return nil, err
+		}
+		if kind > 2 {
+			sub.Warn(WarningUnknownEnum, kind, "/types/record/seq/0")
+		}
+		kinds = append(kinds, kind)
+	}
+	if eof, err := io.EOF(); err != nil {
+		return nil, err
+	} else if !eof {
+		io.Warn(WarningTrailingData, nil, "/seq/1")
+	}
+	return kinds, nil
+}
+
+func TestStream_Warn(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []string
+	}{
+		{"no warnings", []byte{0, 1, 2}, nil},
+		{
+			"unknown enum value and trailing data", []byte{0, 7, 1, 0},
+			[]string{
+				"/types/record/seq/0: at pos 2: warning: unknown enum value, got 7 (0x7)",
+				"/seq/1: at pos 3: warning: trailing data",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			io := NewStream(bytes.NewReader(tt.data))
+			var warnings Warnings
+			io.SetWarningHandler(warnings.Add)
+			if _, err := parseRecords(io); err != nil {
+				t.Fatalf("parseRecords() error = %v", err)
+			}
+			if len(warnings) != len(tt.want) {
+				t.Fatalf("got %d warnings %v, want %d", len(warnings), warnings, len(tt.want))
+			}
+			for i, w := range warnings {
+				if got := w.String(); got != tt.want[i] {
+					t.Errorf("warnings[%d].String() = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+
+	// Without a handler, warnings are ignored
+	io := NewStream(bytes.NewReader([]byte{0, 7, 1, 0}))
+	if io.WarningHandler() != nil {
+		t.Error("WarningHandler() != nil by default")
+	}
+	if _, err := parseRecords(io); err != nil {
+		t.Errorf("parseRecords() error = %v", err)
+	}
+}
+
+func TestWarning_getters(t *testing.T) {
+	io := NewStream(bytes.NewReader([]byte{0, 1, 2, 3}))
+	io.SetErrorContext(1)
+	if _, err := io.ReadBytes(2); err != nil {
+		t.Fatal(err)
+	}
+	w := NewWarning(WarningNonZeroPadding, []byte{1}, io, "/seq/2")
+	if got := w.Kind(); got != WarningNonZeroPadding {
+		t.Errorf("Kind() = %q, want %q", got, WarningNonZeroPadding)
+	}
+	if got := w.Value(); !bytes.Equal(got.([]byte), []byte{1}) {
+		t.Errorf("Value() = %v, want [1]", got)
+	}
+	if got := w.Io(); got != io {
+		t.Errorf("Io() = %p, want %p", got, io)
+	}
+	if got := w.SrcPath(); got != "/seq/2" {
+		t.Errorf("SrcPath() = %q, want %q", got, "/seq/2")
+	}
+	if got := w.Pos(); got != 2 {
+		t.Errorf("Pos() = %v, want 2", got)
+	}
+	if start, got := w.Window(); start != 1 || !bytes.Equal(got, []byte{1, 2}) {
+		t.Errorf("Window() = %v, %v, want 1, [1 2]", start, got)
+	}
+}
+
+func TestWarning_MarshalJSON(t *testing.T) {
+	io := NewStream(bytes.NewReader([]byte{0, 7}))
+	if _, err := io.ReadU1(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(NewWarning(WarningUnknownEnum, 7, io, "/seq/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"kind":"Warning","message":"/seq/0: at pos 1: warning: unknown enum value, got 7 (0x7)","srcPath":"/seq/0","pos":1,` +
+		`"actual":7,"details":{"warning":"unknown enum value"}}`
+	if string(got) != want {
+		t.Errorf("json.Marshal() =\n%s\nwant\n%s", got, want)
+	}
+}
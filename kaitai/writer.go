@@ -2,90 +2,90 @@ package kaitai
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 )
 
+// ErrNotSeekable is returned by Writer.Seek when the underlying writer does
+// not implement io.Seeker.
+var ErrNotSeekable = errors.New("underlying writer is not seekable")
+
 // A Writer encapsulates writing binary data to files and memory.
 type Writer struct {
 	io.Writer
 	buf [8]byte
+
+	// Data not yet passed to the underlying writer, nil if unbuffered
+	wbuf []byte
+
+	// Number of bytes passed to the underlying writer so far, used for
+	// positions when the underlying writer is not an io.Seeker
+	written int64
 }
 
-// NewWriter creates and initializes a new Writer using w.
+// NewWriter creates and initializes a new Writer using w. Every Write* call
+// results in a Write call on w.
 func NewWriter(w io.Writer) *Writer {
 	return &Writer{Writer: w}
 }
 
+// NewBufferedWriter creates and initializes a new Writer using w, which
+// collects written data in an internal buffer of the given size and passes it
+// to w in larger chunks. The output is identical to that of an unbuffered
+// Writer, but Flush or Close must be called once writing is done. Errors of
+// the underlying writer may be reported by a later call than the one whose
+// data failed to be written.
+func NewBufferedWriter(w io.Writer, size int) *Writer {
+	if size <= 0 {
+		size = defaultWriterBufferSize
+	}
+	return &Writer{Writer: w, wbuf: make([]byte, 0, size)}
+}
+
+const defaultWriterBufferSize = 4096
+
 // WriteU1 writes a uint8 to the underlying writer.
 func (k *Writer) WriteU1(v uint8) error {
 	k.buf[0] = v
-	_, err := k.Write(k.buf[:1])
-	if err != nil {
-		return fmt.Errorf("WriteU1: failed to write uint8: %w", err)
-	}
-	return nil
+	return k.write("WriteU1", k.buf[:1])
 }
 
 // WriteU2be writes a uint16 in big-endian order to the underlying writer.
 func (k *Writer) WriteU2be(v uint16) error {
 	binary.BigEndian.PutUint16(k.buf[:2], v)
-	_, err := k.Write(k.buf[:2])
-	if err != nil {
-		return fmt.Errorf("WriteU2be: failed to write uint16: %w", err)
-	}
-	return nil
+	return k.write("WriteU2be", k.buf[:2])
 }
 
 // WriteU4be writes a uint32 in big-endian order to the underlying writer.
 func (k *Writer) WriteU4be(v uint32) error {
 	binary.BigEndian.PutUint32(k.buf[:4], v)
-	_, err := k.Write(k.buf[:4])
-	if err != nil {
-		return fmt.Errorf("WriteU4be: failed to write uint32: %w", err)
-	}
-	return nil
+	return k.write("WriteU4be", k.buf[:4])
 }
 
 // WriteU8be writes a uint64 in big-endian order to the underlying writer.
 func (k *Writer) WriteU8be(v uint64) error {
 	binary.BigEndian.PutUint64(k.buf[:8], v)
-	_, err := k.Write(k.buf[:8])
-	if err != nil {
-		return fmt.Errorf("WriteU8be: failed to write uint64: %w", err)
-	}
-	return nil
+	return k.write("WriteU8be", k.buf[:8])
 }
 
 // WriteU2le writes a uint16 in little-endian order to the underlying writer.
 func (k *Writer) WriteU2le(v uint16) error {
 	binary.LittleEndian.PutUint16(k.buf[:2], v)
-	_, err := k.Write(k.buf[:2])
-	if err != nil {
-		return fmt.Errorf("WriteU2le: failed to write uint16: %w", err)
-	}
-	return nil
+	return k.write("WriteU2le", k.buf[:2])
 }
 
 // WriteU4le writes a uint32 in little-endian order to the underlying writer.
 func (k *Writer) WriteU4le(v uint32) error {
 	binary.LittleEndian.PutUint32(k.buf[:4], v)
-	_, err := k.Write(k.buf[:4])
-	if err != nil {
-		return fmt.Errorf("WriteU4le: failed to write uint32: %w", err)
-	}
-	return nil
+	return k.write("WriteU4le", k.buf[:4])
 }
 
 // WriteU8le writes a uint64 in little-endian order to the underlying writer.
 func (k *Writer) WriteU8le(v uint64) error {
 	binary.LittleEndian.PutUint64(k.buf[:8], v)
-	_, err := k.Write(k.buf[:8])
-	if err != nil {
-		return fmt.Errorf("WriteU8le: failed to write uint64: %w", err)
-	}
-	return nil
+	return k.write("WriteU8le", k.buf[:8])
 }
 
 // WriteS1 writes an int8 to the underlying writer.
@@ -145,9 +145,118 @@ func (k *Writer) WriteF8le(v float64) error {
 
 // WriteBytes writes the byte slice b to the underlying writer.
 func (k *Writer) WriteBytes(b []byte) error {
-	_, err := k.Write(b)
+	return k.write("WriteBytes", b)
+}
+
+// Write writes p, going through the internal buffer if the Writer has one.
+func (k *Writer) Write(p []byte) (int, error) {
+	return k.writeN("Write", p)
+}
+
+// Seek flushes the internal buffer and sets the position of the next write
+// on the underlying writer, interpreted according to whence as described in
+// io.Seeker. The underlying writer must implement io.Seeker.
+func (k *Writer) Seek(offset int64, whence int) (int64, error) {
+	s, ok := k.Writer.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("Seek: %T: %w", k.Writer, ErrNotSeekable)
+	}
+	if err := k.flush("Seek"); err != nil {
+		return 0, err
+	}
+	pos, err := s.Seek(offset, whence)
 	if err != nil {
-		return fmt.Errorf("WriteBytes: failed to write bytes: %w", err)
+		return pos, fmt.Errorf("Seek: error seeking underlying writer: %w", err)
+	}
+	return pos, nil
+}
+
+// Pos returns the position of the next write, including any data still held
+// in the internal buffer. If the underlying writer is not an io.Seeker, this
+// is the number of bytes written so far.
+func (k *Writer) Pos() (int64, error) {
+	if s, ok := k.Writer.(io.Seeker); ok {
+		pos, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return pos, fmt.Errorf("Pos: error getting current position: %w", err)
+		}
+		return pos + int64(len(k.wbuf)), nil
+	}
+	return k.written + int64(len(k.wbuf)), nil
+}
+
+// Flush writes any buffered data to the underlying writer. It does nothing
+// if the Writer is unbuffered.
+func (k *Writer) Flush() error {
+	return k.flush("Flush")
+}
+
+// Close flushes the internal buffer and closes the underlying writer if it
+// implements io.Closer.
+func (k *Writer) Close() error {
+	err := k.flush("Close")
+	if c, ok := k.Writer.(io.Closer); ok {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("Close: error closing underlying writer: %w", cerr)
+		}
+	}
+	return err
+}
+
+func (k *Writer) write(method string, p []byte) error {
+	_, err := k.writeN(method, p)
+	return err
+}
+
+// writeN writes p, either into the internal buffer or, if there is none or p
+// doesn't fit, directly to the underlying writer. It returns the number of
+// bytes of p accepted.
+func (k *Writer) writeN(method string, p []byte) (int, error) {
+	if k.wbuf == nil {
+		return k.writeDirect(method, p)
+	}
+	if len(k.wbuf)+len(p) > cap(k.wbuf) {
+		if err := k.flush(method); err != nil {
+			return 0, err
+		}
+		if len(p) >= cap(k.wbuf) {
+			return k.writeDirect(method, p)
+		}
+	}
+	k.wbuf = append(k.wbuf, p...)
+	return len(p), nil
+}
+
+// flush writes the internal buffer to the underlying writer. On failure, the
+// data which could not be written stays in the buffer.
+func (k *Writer) flush(method string) error {
+	if len(k.wbuf) == 0 {
+		return nil
+	}
+	n, err := k.writeDirect(method, k.wbuf)
+	k.wbuf = k.wbuf[:copy(k.wbuf, k.wbuf[n:])]
+	return err
+}
+
+// writeDirect writes p to the underlying writer. Both failed and short writes
+// are reported as a WriteError attributed to method.
+func (k *Writer) writeDirect(method string, p []byte) (int, error) {
+	n, err := k.Writer.Write(p)
+	k.written += int64(n)
+	if err == nil && n == len(p) {
+		return n, nil
+	}
+	return n, WriteError{method, k.offset() - int64(n), len(p), n, err}
+}
+
+// offset returns the position of the next write on the underlying writer. It
+// is taken from the underlying writer if it is an io.Seeker, otherwise the
+// number of bytes written so far is used.
+func (k *Writer) offset() int64 {
+	if s, ok := k.Writer.(io.Seeker); ok {
+		if pos, err := s.Seek(0, io.SeekCurrent); err == nil {
+			return pos
+		}
 	}
-	return nil
+	return k.written
 }
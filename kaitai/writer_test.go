@@ -2,10 +2,43 @@ package kaitai
 
 import (
 	"bytes"
+	"errors"
+	"io"
 	"reflect"
 	"testing"
 )
 
+// limitedWriter accepts at most n bytes and then returns err, which may be nil
+// to simulate a misbehaving writer.
+type limitedWriter struct {
+	n   int
+	err error
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) <= w.n {
+		w.n -= len(p)
+		return len(p), nil
+	}
+	n := w.n
+	w.n = 0
+	return n, w.err
+}
+
+// limitedByteBuffer is a seekable variant of limitedWriter.
+type limitedByteBuffer struct {
+	*ByteBuffer
+	n int
+}
+
+func (b *limitedByteBuffer) Write(p []byte) (int, error) {
+	if len(p) > b.n {
+		p = p[:b.n]
+	}
+	b.n -= len(p)
+	return b.ByteBuffer.Write(p)
+}
+
 func TestNewWriter(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -425,3 +458,197 @@ func TestWriter_WriteBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestWriter_shortWrite(t *testing.T) {
+	errDiskFull := errors.New("disk full")
+	tests := []struct {
+		name    string
+		w       io.Writer
+		write   func(k *Writer) error
+		want    WriteError
+		wantErr error
+	}{
+		{
+			"short write without error", &limitedWriter{5, nil},
+			func(k *Writer) error { return k.WriteU4be(1) },
+			WriteError{"WriteU4be", 4, 4, 1, nil}, io.ErrShortWrite,
+		},
+		{
+			"underlying error", &limitedWriter{6, errDiskFull},
+			func(k *Writer) error { return k.WriteU8le(1) },
+			WriteError{"WriteU8le", 4, 8, 2, errDiskFull}, errDiskFull,
+		},
+		{
+			"bytes", &limitedWriter{4, nil},
+			func(k *Writer) error { return k.WriteBytes([]byte("test")) },
+			WriteError{"WriteBytes", 4, 4, 0, nil}, io.ErrShortWrite,
+		},
+		{
+			"seekable", &limitedByteBuffer{NewByteBuffer(), 5},
+			func(k *Writer) error {
+				if _, err := k.Writer.(io.Seeker).Seek(2, io.SeekStart); err != nil {
+					return err
+				}
+				return k.WriteU2le(1)
+			},
+			WriteError{"WriteU2le", 2, 2, 1, nil}, io.ErrShortWrite,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := NewWriter(tt.w)
+			if err := k.WriteU4le(0); err != nil {
+				t.Fatalf("Writer.WriteU4le() error = %v", err)
+			}
+			err := tt.write(k)
+			var got WriteError
+			if !errors.As(err, &got) {
+				t.Fatalf("error = %v, want WriteError", err)
+			}
+			if got != tt.want {
+				t.Errorf("error = %#v, want %#v", got, tt.want)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want it to wrap %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// countingWriter counts the Write calls reaching it.
+type countingWriter struct {
+	bytes.Buffer
+	calls int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.calls++
+	return w.Buffer.Write(p)
+}
+
+func writeMixed(k *Writer) error {
+	for i := 0; i < 100; i++ {
+		if err := k.WriteU1(uint8(i)); err != nil {
+			return err
+		}
+		if err := k.WriteU4be(uint32(i)); err != nil {
+			return err
+		}
+	}
+	if err := k.WriteBytes(bytes.Repeat([]byte{0xaa}, 100)); err != nil {
+		return err
+	}
+	return k.WriteF8le(1.5)
+}
+
+func TestNewBufferedWriter(t *testing.T) {
+	unbuffered := &countingWriter{}
+	if err := writeMixed(NewWriter(unbuffered)); err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{1, 7, 64, 0} {
+		buffered := &countingWriter{}
+		k := NewBufferedWriter(buffered, size)
+		if err := writeMixed(k); err != nil {
+			t.Fatalf("size %d: write error = %v", size, err)
+		}
+		if err := k.Flush(); err != nil {
+			t.Fatalf("size %d: Writer.Flush() error = %v", size, err)
+		}
+		if !bytes.Equal(buffered.Bytes(), unbuffered.Bytes()) {
+			t.Errorf("size %d: buffered output = %v, want %v", size, buffered.Bytes(), unbuffered.Bytes())
+		}
+		if size > 1 && buffered.calls >= unbuffered.calls {
+			t.Errorf("size %d: %d Write calls, want fewer than %d", size, buffered.calls, unbuffered.calls)
+		}
+	}
+}
+
+func TestWriter_Seek(t *testing.T) {
+	buf := NewByteBuffer()
+	k := NewBufferedWriter(buf, 16)
+	if err := k.WriteU4le(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.WriteBytes([]byte("payload")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := k.Pos(); err != nil || got != 11 {
+		t.Errorf("Writer.Pos() = %v, %v, want %v", got, err, 11)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("underlying writer got %d bytes before Seek, want 0", buf.Len())
+	}
+	if _, err := k.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Writer.Seek() error = %v", err)
+	}
+	if err := k.WriteU4le(7); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Seek(0, io.SeekEnd); err != nil {
+		t.Fatalf("Writer.Seek() error = %v", err)
+	}
+	if err := k.WriteU1(0xff); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := append([]byte{7, 0, 0, 0}, "payload\xff"...)
+	if got := buf.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("output = %v, want %v", got, want)
+	}
+
+	if _, err := NewWriter(&bytes.Buffer{}).Seek(0, io.SeekStart); !errors.Is(err, ErrNotSeekable) {
+		t.Errorf("Writer.Seek() error = %v, want %v", err, ErrNotSeekable)
+	}
+}
+
+func TestWriter_Flush(t *testing.T) {
+	w := &limitedWriter{3, nil}
+	k := NewBufferedWriter(w, 16)
+	if err := k.WriteU4be(0x01020304); err != nil {
+		t.Fatalf("Writer.WriteU4be() error = %v", err)
+	}
+	err := k.Flush()
+	want := WriteError{"Flush", 0, 4, 3, nil}
+	var got WriteError
+	if !errors.As(err, &got) || got != want {
+		t.Fatalf("Writer.Flush() error = %#v, want %#v", err, want)
+	}
+	// The byte which could not be written stays buffered
+	w.n = 1
+	if err := k.Flush(); err != nil {
+		t.Errorf("Writer.Flush() error = %v", err)
+	}
+	if pos, _ := k.Pos(); pos != 4 {
+		t.Errorf("Writer.Pos() = %v, want %v", pos, 4)
+	}
+}
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestWriter_Close(t *testing.T) {
+	w := &closeRecorder{}
+	k := NewBufferedWriter(w, 16)
+	if err := k.WriteU2be(0x0102); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Close(); err != nil {
+		t.Fatalf("Writer.Close() error = %v", err)
+	}
+	if !w.closed {
+		t.Error("Writer.Close() did not close the underlying writer")
+	}
+	if got := w.Bytes(); !bytes.Equal(got, []byte{1, 2}) {
+		t.Errorf("output = %v, want %v", got, []byte{1, 2})
+	}
+}